@@ -5,7 +5,6 @@ import (
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/rdsdataservice"
 	"github.com/mmatagrin/ctxerror"
-	"io/ioutil"
 	"os"
 	"reflect"
 	"strings"
@@ -19,63 +18,34 @@ func SetAwsSession(sess session.Session) {
 	awsSession = &sess
 }
 
+//runs the statements of the file in a single transaction, see ExecuteScript
 func ExecuteFile(filePath, separator string, connexion AuroraConnexion) (e ctxerror.CtxErrorTraceI){
-	context := ctxerror.SetContext(map[string]interface{}{})
+	context := ctxerror.SetContext(map[string]interface{}{
+		"file": filePath,
+	})
 
 	file, err := os.Open(filePath)
 	if err != nil{
 		return context.Wrap(err, "unable to open source file")
 	}
+	defer file.Close()
 
-	sqlQueriesBytes, err := ioutil.ReadAll(file)
-	if err != nil {
-		return context.Wrap(err, "unable to read source file")
-	}
-
-	sqlQueries := string(sqlQueriesBytes)
-
-
-	transaction , err := BeginTransaction(connexion)
-	if err != nil {
-		return context.Wrap(err, "enable to start database transaction")
-	}
-
-	defer func() {
-		if e != nil {
-			errRollback := RollbackTransaction(connexion, transaction)
-			if errRollback != nil {
-				e = e.AddError(errRollback, "unable to rollback transaction")
-			}
-
-			return
-		}
-
-		errCommit := CommitTransaction(connexion, transaction)
-		if errCommit != nil {
-			e = ctxerror.Wrap(errCommit, "unable to commit transaction")
-		}
-	}()
-
-	for _, query := range strings.SplitAfter(sqlQueries, separator) {
-		if query == "" {
-			continue
-		}
-
-		_, err = PerformAuroraQuery(query, nil, connexion, &transaction)
-
-		if err != nil {
-			context.AddContext("current_query", string(query))
-			return context.Wrap(err, "unable to perform query")
-		}
+	if err := ExecuteScript(file, separator, connexion); err != nil {
+		return context.Wrap(err, "unable to execute source file")
 	}
 
 	return nil
 }
 
 func PerformAuroraQuery(query string, parameters map[string]interface{}, connexion AuroraConnexion, transactionId *string) (*rdsdataservice.ExecuteStatementOutput, error) {
+	return performAuroraQuery("", GetDefaultDialect(), query, parameters, connexion, transactionId)
+}
+
+//name labels the query in the hooks and metrics, dialect is the dialect the query was generated for
+func performAuroraQuery(name string, dialect Dialect, query string, parameters map[string]interface{}, connexion AuroraConnexion, transactionId *string) (*rdsdataservice.ExecuteStatementOutput, error) {
 	context := ctxerror.SetContext(map[string]interface{}{
 		"query": query,
-		"parameters": parameters,
+		"parameters": RedactParameters(parameters),
 	})
 
 	if awsSession == nil {
@@ -90,7 +60,7 @@ func PerformAuroraQuery(query string, parameters map[string]interface{}, connexi
 		for key, value := range parameters {
 			field, err := valueToRdsField(value)
 			if err != nil {
-				context.AddContext("current_parameter", []interface{}{key, value})
+				context.AddContext("current_parameter", []interface{}{key, redactParameter(key, value)})
 				return nil, context.Wrap(err, "error converting parameter to Rds field")
 			}
 			param := &rdsdataservice.SqlParameter{
@@ -113,7 +83,25 @@ func PerformAuroraQuery(query string, parameters map[string]interface{}, connexi
 		executeStatementInput.TransactionId = transactionId
 	}
 
-	res, err := rdsClient.ExecuteStatement(&executeStatementInput)
+	event := &QueryEvent{
+		Operation: EXECUTE_STATEMENT,
+		Name: name,
+		Dialect: dialect,
+		Connexion: connexion,
+		Sql: query,
+		Parameters: RedactParameters(parameters),
+		TransactionId: aws.StringValue(transactionId),
+	}
+
+	var res *rdsdataservice.ExecuteStatementOutput
+	err := instrument(event, func() (err error) {
+		res, err = rdsClient.ExecuteStatement(&executeStatementInput)
+		if res != nil {
+			event.RowsAffected = aws.Int64Value(res.NumberOfRecordsUpdated)
+			event.RowsReturned = len(res.Records)
+		}
+		return err
+	})
 
 	if err != nil {
 		if strings.Contains(err.Error(), "Communications link failure") {
@@ -129,9 +117,13 @@ func PerformAuroraQuery(query string, parameters map[string]interface{}, connexi
 }
 
 func PerformAuroraQueries(query string, parameters []map[string]interface{}, connexion AuroraConnexion, transactionId *string) (*rdsdataservice.BatchExecuteStatementOutput, error) {
+	return performAuroraQueries("", GetDefaultDialect(), query, parameters, connexion, transactionId)
+}
+
+func performAuroraQueries(name string, dialect Dialect, query string, parameters []map[string]interface{}, connexion AuroraConnexion, transactionId *string) (*rdsdataservice.BatchExecuteStatementOutput, error) {
 	context := ctxerror.SetContext(map[string]interface{}{
 		"query": query,
-		"parameters": parameters,
+		"parameters": redactParameterSets(parameters),
 	})
 
 	if awsSession == nil {
@@ -145,11 +137,11 @@ func PerformAuroraQueries(query string, parameters []map[string]interface{}, con
 		sqlParams = make([][]*rdsdataservice.SqlParameter, 0, len(parameters))
 
 		for _, rowParameters := range parameters {
-			rowParams := make([]*rdsdataservice.SqlParameter, 0, len(parameters))
+			rowParams := make([]*rdsdataservice.SqlParameter, 0, len(rowParameters))
 			for key, value := range rowParameters {
 				field, err := valueToRdsField(value)
 				if err != nil {
-					context.AddContext("current_parameter", []interface{}{key, value})
+					context.AddContext("current_parameter", []interface{}{key, redactParameter(key, value)})
 					return nil, context.Wrap(err, "error converting parameter to Rds field")
 				}
 				param := &rdsdataservice.SqlParameter{
@@ -176,7 +168,21 @@ func PerformAuroraQueries(query string, parameters []map[string]interface{}, con
 		executeStatementInput.TransactionId = transactionId
 	}
 
-	res, err := rdsClient.BatchExecuteStatement(&executeStatementInput)
+	event := &QueryEvent{
+		Operation: BATCH_EXECUTE_STATEMENT,
+		Name: name,
+		Dialect: dialect,
+		Connexion: connexion,
+		Sql: query,
+		ParameterSets: redactParameterSets(parameters),
+		TransactionId: aws.StringValue(transactionId),
+	}
+
+	var res *rdsdataservice.BatchExecuteStatementOutput
+	err := instrument(event, func() (err error) {
+		res, err = rdsClient.BatchExecuteStatement(&executeStatementInput)
+		return err
+	})
 
 	if err != nil {
 		if strings.Contains(err.Error(), "Communications link failure") {
@@ -226,10 +232,23 @@ func BeginTransaction(connexion AuroraConnexion) (string, error) {
 
 	rdsClient := rdsdataservice.New(awsSession)
 
-	output, err := rdsClient.BeginTransaction(&rdsdataservice.BeginTransactionInput{
-		Database: aws.String(connexion.Database),
-		ResourceArn: aws.String(connexion.ResourceArn),
-		SecretArn: aws.String(connexion.SecretArn),
+	event := &QueryEvent{
+		Operation: BEGIN_TRANSACTION,
+		Dialect: GetDefaultDialect(),
+		Connexion: connexion,
+	}
+
+	var output *rdsdataservice.BeginTransactionOutput
+	err := instrument(event, func() (err error) {
+		output, err = rdsClient.BeginTransaction(&rdsdataservice.BeginTransactionInput{
+			Database: aws.String(connexion.Database),
+			ResourceArn: aws.String(connexion.ResourceArn),
+			SecretArn: aws.String(connexion.SecretArn),
+		})
+		if output != nil {
+			event.TransactionId = aws.StringValue(output.TransactionId)
+		}
+		return err
 	})
 
 	if err != nil {
@@ -251,10 +270,20 @@ func CommitTransaction(connexion AuroraConnexion, transactionId string) error {
 
 	rdsClient := rdsdataservice.New(awsSession)
 
-	_, err := rdsClient.CommitTransaction(&rdsdataservice.CommitTransactionInput{
-		ResourceArn: aws.String(connexion.ResourceArn),
-		SecretArn: aws.String(connexion.SecretArn),
-		TransactionId: &transactionId,
+	event := &QueryEvent{
+		Operation: COMMIT_TRANSACTION,
+		Dialect: GetDefaultDialect(),
+		Connexion: connexion,
+		TransactionId: transactionId,
+	}
+
+	err := instrument(event, func() error {
+		_, err := rdsClient.CommitTransaction(&rdsdataservice.CommitTransactionInput{
+			ResourceArn: aws.String(connexion.ResourceArn),
+			SecretArn: aws.String(connexion.SecretArn),
+			TransactionId: &transactionId,
+		})
+		return err
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "Communications link failure") {
@@ -276,10 +305,20 @@ func RollbackTransaction(connexion AuroraConnexion, transactionId string) error
 
 	rdsClient := rdsdataservice.New(awsSession)
 
-	_, err := rdsClient.RollbackTransaction(&rdsdataservice.RollbackTransactionInput{
-		ResourceArn: aws.String(connexion.ResourceArn),
-		SecretArn: aws.String(connexion.SecretArn),
-		TransactionId: &transactionId,
+	event := &QueryEvent{
+		Operation: ROLLBACK_TRANSACTION,
+		Dialect: GetDefaultDialect(),
+		Connexion: connexion,
+		TransactionId: transactionId,
+	}
+
+	err := instrument(event, func() error {
+		_, err := rdsClient.RollbackTransaction(&rdsdataservice.RollbackTransactionInput{
+			ResourceArn: aws.String(connexion.ResourceArn),
+			SecretArn: aws.String(connexion.SecretArn),
+			TransactionId: &transactionId,
+		})
+		return err
 	})
 	if err != nil {
 		if strings.Contains(err.Error(), "Communications link failure") {
@@ -292,9 +331,35 @@ func RollbackTransaction(connexion AuroraConnexion, transactionId string) error
 	return nil
 }
 
+//runs call in transactionId, or in a new transaction when transactionId is nil: committed when call succeeds, rolled back otherwise
+func inTransaction(connexion AuroraConnexion, transactionId *string, call func(transactionId *string) error) error {
+	if transactionId != nil {
+		return call(transactionId)
+	}
+
+	transaction, err := BeginTransaction(connexion)
+	if err != nil {
+		return ctxerror.Wrap(err, "unable to start database transaction")
+	}
+
+	if err := call(&transaction); err != nil {
+		if errRollback := RollbackTransaction(connexion, transaction); errRollback != nil {
+			return ctxerror.Wrap(err, "transaction failed").AddError(errRollback, "unable to rollback transaction")
+		}
+
+		return err
+	}
+
+	if err := CommitTransaction(connexion, transaction); err != nil {
+		return ctxerror.Wrap(err, "unable to commit transaction")
+	}
+
+	return nil
+}
+
 func valueToRdsField(value interface{}) (*rdsdataservice.Field, error) {
 	context := ctxerror.SetContext(map[string]interface{}{
-		"value": value,
+		"value": redactValue(value),
 	})
 
 	if value == nil {
@@ -303,6 +368,15 @@ func valueToRdsField(value interface{}) (*rdsdataservice.Field, error) {
 		}, nil
 	}
 
+	switch sensitive := value.(type) {
+	case Sensitive:
+		return valueToRdsField(sensitive.Value)
+	case *Sensitive:
+		if sensitive != nil {
+			return valueToRdsField(sensitive.Value)
+		}
+	}
+
 	if reflect.TypeOf(value).Kind() == reflect.Ptr {
 		//its a pointer, and it cant be nil cause we checked above
 		rValue := reflect.ValueOf(value)
@@ -0,0 +1,146 @@
+package aurora
+
+import (
+	"github.com/aws/aws-sdk-go/service/rdsdataservice"
+	"github.com/mmatagrin/ctxerror"
+)
+
+//conservative limits of a single Data API batch
+const (
+	DefaultMaxBatchParameterSets = 1000
+	DefaultMaxBatchPayloadSize   = 1024 * 1024
+)
+
+type BatchOptions struct {
+	MaxParameterSets int  //parameter sets per call, DefaultMaxBatchParameterSets when 0
+	MaxPayloadSize   int  //bytes of parameter values per call, DefaultMaxBatchPayloadSize when 0
+	Transaction      bool //see BulkInsertOptions.Transaction
+}
+
+type BatchResult struct {
+	//one result per parameter set, in the same order
+	UpdateResults []BatchUpdateResult
+}
+
+//the Data API does not return the number of records updated by each parameter set, only the generated fields
+type BatchUpdateResult struct {
+	GeneratedFields []interface{}
+}
+
+//executes the query once per parameter set, ex: a Delete("t").Where("id = :id") with [{"id": 1}, {"id": 2}].
+//the parameters of the query are sent with every set, the set values take precedence over the ones given to the builder.
+//the sets are split into as many BatchExecuteStatement calls as needed to respect the Data API limits
+func (aq *AuroraQuery) ExecuteBatch(connexion AuroraConnexion, parameterSets []map[string]interface{}, transactionId *string, options BatchOptions) (*BatchResult, error) {
+	sqlStr := aq.GetSql()
+	context := ctxerror.SetContext(map[string]interface{}{
+		"connexion": connexion,
+		"query": sqlStr,
+		"transactionId": transactionId,
+		"options": options,
+	})
+
+	if err := aq.check(); err != nil {
+		return nil, context.Wrap(err, "unable to prepare query")
+	}
+
+	parameters := aq.givenParameters()
+	sets := make([]map[string]interface{}, len(parameterSets))
+	for i, parameterSet := range parameterSets {
+		if err := aq.checkParameters(parameterSet); err != nil {
+			context.AddContext("set", i)
+			return nil, context.Wrap(err, "invalid parameter set")
+		}
+
+		set := make(map[string]interface{}, len(parameters)+len(parameterSet)+len(aq.boundParameters))
+		for key, value := range parameters {
+			set[key] = value
+		}
+		for key, value := range parameterSet {
+			set[key] = value
+		}
+		for key, value := range aq.boundParameters {
+			set[key] = value
+		}
+		sets[i] = set
+	}
+
+	result := &BatchResult{}
+	execute := func(transactionId *string) error {
+		for i, chunk := range chunkParameterSets(sets, options) {
+			res, err := performAuroraQueries(aq.name, aq.AuroraQueryBuilder.getDialect(), sqlStr, chunk, connexion, transactionId)
+			if err != nil {
+				context.AddContext("chunk", i)
+				return context.Wrap(err, "unable to perform batch query")
+			}
+
+			updateResults, err := parseUpdateResults(res.UpdateResults)
+			if err != nil {
+				context.AddContext("chunk", i)
+				return context.Wrap(err, "unable to parse batch results")
+			}
+			result.UpdateResults = append(result.UpdateResults, updateResults...)
+		}
+
+		return nil
+	}
+
+	var err error
+	if options.Transaction {
+		err = inTransaction(connexion, transactionId, execute)
+	} else {
+		err = execute(transactionId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func parseUpdateResults(updateResults []*rdsdataservice.UpdateResult) ([]BatchUpdateResult, error) {
+	results := make([]BatchUpdateResult, len(updateResults))
+	for i, updateResult := range updateResults {
+		if updateResult == nil {
+			continue
+		}
+
+		for _, field := range updateResult.GeneratedFields {
+			value, err := rdsFieldToValue(field)
+			if err != nil {
+				return nil, err
+			}
+			results[i].GeneratedFields = append(results[i].GeneratedFields, value)
+		}
+	}
+
+	return results, nil
+}
+
+//splits the parameter sets so that every chunk stays under the limits, a set alone above the limits gets its own chunk
+func chunkParameterSets(sets []map[string]interface{}, options BatchOptions) [][]map[string]interface{} {
+	maxParameterSets := options.MaxParameterSets
+	if maxParameterSets <= 0 {
+		maxParameterSets = DefaultMaxBatchParameterSets
+	}
+
+	maxPayloadSize := options.MaxPayloadSize
+	if maxPayloadSize <= 0 {
+		maxPayloadSize = DefaultMaxBatchPayloadSize
+	}
+
+	bounds := chunkBounds(len(sets), maxParameterSets, maxPayloadSize, func(i int) (int, int) {
+		setSize := 0
+		for key, value := range sets[i] {
+			setSize += len(key) + parameterSize(value)
+		}
+
+		return 1, setSize
+	})
+
+	chunks := make([][]map[string]interface{}, len(bounds))
+	for i, bound := range bounds {
+		chunks[i] = sets[bound[0]:bound[1]]
+	}
+
+	return chunks
+}
@@ -0,0 +1,157 @@
+package aurora
+
+import (
+	"github.com/mmatagrin/ctxerror"
+)
+
+//conservative limits of a single Data API statement
+const (
+	DefaultMaxInsertParameters  = 1000
+	DefaultMaxInsertPayloadSize = 64 * 1024
+)
+
+type BulkInsertOptions struct {
+	MaxParameters  int  //parameters per statement, DefaultMaxInsertParameters when 0
+	MaxPayloadSize int  //bytes of sql and parameter values per statement, DefaultMaxInsertPayloadSize when 0
+	Transaction    bool //runs all the chunks in a single transaction, ignored when a transactionId is given
+}
+
+//executes an insert built with Values or Rows, split into as many statements as needed to respect the Data API limits
+func (aq *AuroraQuery) ExecuteBulkInsert(connexion AuroraConnexion, transactionId *string, options BulkInsertOptions) (*InsertResult, error) {
+	context := ctxerror.SetContext(map[string]interface{}{
+		"connexion": connexion,
+		"transactionId": transactionId,
+		"options": options,
+	})
+
+	insert := aq.AuroraQueryBuilder.query.Insert
+	if aq.QueryType != INSERT || insert.Select != nil {
+		return nil, context.New("bulk insert expects an insert query built with Values or Rows")
+	}
+
+	chunks := chunkInsertRows(aq.withInsertValues(nil).GetSql(), insert.Values, options)
+
+	result := &InsertResult{}
+	execute := func(transactionId *string) error {
+		for i, rows := range chunks {
+			chunk := aq.withInsertValues(rows)
+
+			res, err := chunk.perform(connexion, transactionId)
+			if err != nil {
+				context.AddContext("chunk", i)
+				context.AddContext("sql_query", chunk.GetSql())
+				return context.Wrap(err, "unable to perform insert query")
+			}
+
+			chunkResult, err := chunk.newInsertResult(res)
+			if err != nil {
+				context.AddContext("chunk", i)
+				return context.Wrap(err, "unable to parse insert result")
+			}
+
+			result.merge(chunkResult)
+		}
+
+		return nil
+	}
+
+	var err error
+	if options.Transaction {
+		err = inTransaction(connexion, transactionId, execute)
+	} else {
+		err = execute(transactionId)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+//copy of the query inserting rows instead of the original values
+func (aq *AuroraQuery) withInsertValues(rows [][]interface{}) *AuroraQuery {
+	builder := aq.AuroraQueryBuilder
+	builder.query.Insert.Values = rows
+
+	chunk := builder.GetQuery()
+	chunk.parameters = aq.parameters
+	chunk.name = aq.name
+	return chunk
+}
+
+//splits rows so that every chunk stays under the parameters and payload limits, a row alone above the limits gets its own chunk
+func chunkInsertRows(emptyInsertSql string, rows [][]interface{}, options BulkInsertOptions) [][][]interface{} {
+	maxParameters := options.MaxParameters
+	if maxParameters <= 0 {
+		maxParameters = DefaultMaxInsertParameters
+	}
+
+	maxPayloadSize := options.MaxPayloadSize
+	if maxPayloadSize <= 0 {
+		maxPayloadSize = DefaultMaxInsertPayloadSize
+	}
+
+	bounds := chunkBounds(len(rows), maxParameters, maxPayloadSize-len(emptyInsertSql), func(i int) (int, int) {
+		return len(rows[i]), rowPayloadSize(rows[i])
+	})
+
+	chunks := make([][][]interface{}, len(bounds))
+	for i, bound := range bounds {
+		chunks[i] = rows[bound[0]:bound[1]]
+	}
+
+	return chunks
+}
+
+//splits count items into chunks of consecutive items whose total weight and size stay under the limits, an item alone
+//above the limits gets its own chunk. item returns the weight and the size of the item i, the chunks are returned as
+//their [start, end) bounds
+func chunkBounds(count int, maxWeight int, maxSize int, item func(i int) (int, int)) [][2]int {
+	var bounds [][2]int
+	start, chunkWeight, chunkSize := 0, 0, 0
+	for i := 0; i < count; i++ {
+		weight, size := item(i)
+
+		if i > start && (chunkWeight+weight > maxWeight || chunkSize+size > maxSize) {
+			bounds = append(bounds, [2]int{start, i})
+			start, chunkWeight, chunkSize = i, 0, 0
+		}
+
+		chunkWeight += weight
+		chunkSize += size
+	}
+
+	if start < count {
+		bounds = append(bounds, [2]int{start, count})
+	}
+
+	return bounds
+}
+
+//approximate size of a row in the sql statement and its parameters
+func rowPayloadSize(row []interface{}) int {
+	//"(" + ")" + ","
+	size := 3
+	for _, value := range row {
+		//placeholder and parameter name
+		size += 2 * len(":insert_000_000,")
+		size += parameterSize(value)
+	}
+
+	return size
+}
+
+func parameterSize(value interface{}) int {
+	switch t := value.(type) {
+	case string:
+		return len(t)
+	case *string:
+		if t != nil {
+			return len(*t)
+		}
+	case []byte:
+		return len(t)
+	}
+
+	return 8
+}
@@ -0,0 +1,124 @@
+package aurora
+
+import (
+	"encoding/hex"
+	"github.com/mmatagrin/ctxerror"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//format of the timestamps expected by the Data API
+const TIMESTAMP_FORMAT = "2006-01-02 15:04:05.999999"
+
+//FOR DEBUGGING ONLY: returns the sql with its parameters inlined as literals, so that it can be pasted in a sql client.
+//never execute it, the queries are sent with GetSql and Params. the parameters hidden by the redaction policy are inlined as '[REDACTED]'
+func (aq *AuroraQuery) DebugSql() (string, error) {
+	sqlStr := aq.GetSql()
+	dialect := aq.AuroraQueryBuilder.getDialect()
+	parameters := RedactParameters(aq.Params())
+
+	context := ctxerror.SetContext(map[string]interface{}{
+		"query": sqlStr,
+	})
+
+	var debugSql strings.Builder
+	for i := 0; i < len(sqlStr); i++ {
+		char := sqlStr[i]
+
+		switch length := stringLiteralLength(sqlStr, i, dialect); {
+		case length != 0:
+			debugSql.WriteString(sqlStr[i : i+length])
+			i += length - 1
+		case char == '`' || char == '"':
+			end := quotedIdentifierEnd(sqlStr, i)
+			debugSql.WriteString(sqlStr[i : end+1])
+			i = end
+		case char == ':' && i+1 < len(sqlStr) && sqlStr[i+1] == ':':
+			//postgres cast
+			debugSql.WriteString("::")
+			i++
+		case char == ':' && i+1 < len(sqlStr) && isParameterChar(sqlStr[i+1]):
+			end := i + 1
+			for end < len(sqlStr) && isParameterChar(sqlStr[end]) {
+				end++
+			}
+
+			name := sqlStr[i+1 : end]
+			value, ok := parameters[name]
+			if !ok {
+				return "", context.New("missing parameter " + name)
+			}
+
+			literal, err := dialect.literal(value)
+			if err != nil {
+				context.AddContext("parameter", name)
+				return "", context.Wrap(err, "unable to inline parameter")
+			}
+
+			debugSql.WriteString(literal)
+			i = end - 1
+		default:
+			debugSql.WriteByte(char)
+		}
+	}
+
+	return debugSql.String(), nil
+}
+
+func isParameterChar(char byte) bool {
+	return isNameChar(char) && char != ':' && char != '.' && char != '$'
+}
+
+//value written as a sql literal of the dialect, time.Time is written like a timestamp string
+func (d Dialect) literal(value interface{}) (string, error) {
+	if value == nil {
+		return "NULL", nil
+	}
+
+	if sensitive, ok := value.(Sensitive); ok {
+		return d.literal(sensitive.Value)
+	}
+
+	if reflect.TypeOf(value).Kind() == reflect.Ptr {
+		rValue := reflect.ValueOf(value)
+		if rValue.IsNil() {
+			return "NULL", nil
+		}
+		value = rValue.Elem().Interface()
+	}
+
+	switch t := value.(type) {
+	case []byte:
+		if d == POSTGRESQL {
+			return `'\x` + hex.EncodeToString(t) + `'::bytea`, nil
+		}
+		return "X'" + hex.EncodeToString(t) + "'", nil
+	case bool:
+		if t {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64), nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case string:
+		return d.stringLiteral(t), nil
+	case time.Time:
+		return d.stringLiteral(t.Format(TIMESTAMP_FORMAT)), nil
+	default:
+		return "", ctxerror.New("unknown type: " + reflect.TypeOf(value).String() + ", supported types are: float64, bool, []byte, int64, nil, string, time.Time")
+	}
+}
+
+func (d Dialect) stringLiteral(value string) string {
+	value = strings.Replace(value, "'", "''", -1)
+	if d != POSTGRESQL {
+		//mysql treats the backslash as an escape character
+		value = strings.Replace(value, `\`, `\\`, -1)
+	}
+
+	return "'" + value + "'"
+}
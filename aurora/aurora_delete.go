@@ -1,58 +1,62 @@
 package aurora
 
 import (
-	"fmt"
 	"github.com/mmatagrin/ctxerror"
 )
 
 type AuroraDeleteStruct struct {
-	tableName string
-	where  []string
-	orWhere []string
+	builder    AuroraQueryBuilder
+	parameters map[string]interface{}
 }
 
 func AuroraDelete(tableName string) *AuroraDeleteStruct {
-	return &AuroraDeleteStruct{tableName: tableName}
+	builder := AuroraQueryBuilder{}
+	builder.Delete(tableName)
+
+	return &AuroraDeleteStruct{builder: builder}
 }
 
 func (ads *AuroraDeleteStruct) Where(condition string) *AuroraDeleteStruct {
-	ads.where = append(ads.where, condition)
+	ads.builder.Where("(" + condition + ")")
 	return ads
 }
 
 func (ads *AuroraDeleteStruct) OrWhere(condition string) *AuroraDeleteStruct {
-	ads.orWhere = append(ads.where, condition)
+	ads.builder.OrWhere("(" + condition + ")")
+	return ads
+}
+
+//default parameters of the delete, the values given to ExecuteDelete take precedence
+func (ads *AuroraDeleteStruct) SetParameters(parameters map[string]interface{}) *AuroraDeleteStruct {
+	ads.parameters = parameters
 	return ads
 }
 
+func (ads *AuroraDeleteStruct) GetSql() string {
+	return ads.getQuery(nil).GetSql()
+}
 
+func (ads *AuroraDeleteStruct) Params() map[string]interface{} {
+	return ads.getQuery(nil).Params()
+}
 
+//the delete can be executed several times, with different values
 func (ads *AuroraDeleteStruct) ExecuteDelete(connexion AuroraConnexion, values map[string]interface{}, transactionId *string) (int64, error) {
 	context := ctxerror.SetContext(map[string]interface{}{
 		"connexion": connexion,
-		"values": values,
+		"values": RedactParameters(values),
 	})
 
-	sqlStr := fmt.Sprintf("DELETE FROM %s WHERE 1 ", ads.tableName)
-
-	for _, condition := range ads.where {
-
-		sqlStr += " AND (" + condition + ")"
-
-	}
-
-	for _, condition  := range ads.orWhere {
-		sqlStr += " OR (" + condition + ")"
-	}
-
-	res, err := PerformAuroraQuery(sqlStr, values, connexion, transactionId)
+	res, err := ads.getQuery(values).Execute(connexion, transactionId)
 	if err != nil {
 		return 0, context.Wrap(err, "unable to perform delete query")
 	}
 
-	if res.NumberOfRecordsUpdated != nil {
-		return *res.NumberOfRecordsUpdated, nil
-	}
+	return  res, nil
+}
 
-	return  0, nil
+//a new query is built every time so that rendering it never alters the delete
+func (ads *AuroraDeleteStruct) getQuery(values map[string]interface{}) *AuroraQuery {
+	builder := ads.builder
+	return builder.GetQuery().SetParameters(mergeParameters(ads.parameters, values))
 }
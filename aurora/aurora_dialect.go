@@ -0,0 +1,112 @@
+package aurora
+
+import (
+	"github.com/mmatagrin/sql-builder/structs"
+	"strconv"
+	"strings"
+)
+
+type Dialect string
+
+const (
+	MYSQL      Dialect = "mysql"
+	POSTGRESQL Dialect = "postgresql"
+)
+
+var defaultDialect = MYSQL
+
+//dialect used by the builders that did not call AuroraQueryBuilder.Dialect
+func SetDefaultDialect(dialect Dialect) {
+	defaultDialect = dialect
+}
+
+func GetDefaultDialect() Dialect {
+	return defaultDialect
+}
+
+func (d Dialect) quote(identifier string) string {
+	if d == POSTGRESQL {
+		return `"` + strings.Replace(identifier, `"`, `""`, -1) + `"`
+	}
+
+	return "`" + strings.Replace(identifier, "`", "``", -1) + "`"
+}
+
+func (d Dialect) limit(offset int, count int) string {
+	if d == POSTGRESQL {
+		return "LIMIT " + strconv.Itoa(count) + " OFFSET " + strconv.Itoa(offset) + " "
+	}
+
+	return "LIMIT " + strconv.Itoa(offset) + "," + strconv.Itoa(count) + " "
+}
+
+func (d Dialect) insertVerb(mode int) string {
+	switch {
+	case mode == INSERT_IGNORE && d != POSTGRESQL:
+		return "INSERT IGNORE INTO "
+	case mode == REPLACE:
+		return "REPLACE INTO "
+	default:
+		return "INSERT INTO "
+	}
+}
+
+func (d Dialect) insertSuffix(insert structs.Insert) string {
+	var sqlStr string
+	if len(insert.Updates) != 0 {
+		sqlStr = d.upsert(insert)
+	} else if insert.Mode == INSERT_IGNORE && d == POSTGRESQL {
+		sqlStr = " ON CONFLICT DO NOTHING"
+	}
+
+	if len(insert.Returning) != 0 {
+		sqlStr += " RETURNING " + strings.Join(quoteIdentifiers(insert.Returning, d), ",")
+	}
+
+	return sqlStr
+}
+
+func (d Dialect) upsert(insert structs.Insert) string {
+	assignments := make([]string, len(insert.Updates))
+	for i, update := range insert.Updates {
+		column := quoteIdentifier(update.Column, d)
+		expression := update.Expression
+		if expression == "" {
+			expression = d.insertedValue(insert, update.Column)
+		}
+		//mysql has no WHERE for the update, the columns keep their value when the condition is not met
+		if insert.UpdateCondition != "" && d != POSTGRESQL {
+			expression = "IF(" + insert.UpdateCondition + ", " + expression + ", " + column + ")"
+		}
+		assignments[i] = column + " = " + expression
+	}
+
+	if d == POSTGRESQL {
+		sqlStr := " ON CONFLICT (" + strings.Join(quoteIdentifiers(insert.OnConflict, d), ",") + ") DO UPDATE SET " + strings.Join(assignments, ", ")
+		if insert.UpdateCondition != "" {
+			sqlStr += " WHERE " + insert.UpdateCondition
+		}
+
+		return sqlStr
+	}
+
+	var sqlStr string
+	if insert.Alias != "" {
+		sqlStr = " AS " + insert.Alias
+	}
+
+	return sqlStr + " ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+}
+
+//reference to the value that was being inserted in column
+func (d Dialect) insertedValue(insert structs.Insert, column string) string {
+	column = quoteIdentifier(column, d)
+	switch {
+	case d == POSTGRESQL:
+		return "EXCLUDED." + column
+	case insert.Alias != "":
+		return insert.Alias + "." + column
+	default:
+		return "VALUES(" + column + ")"
+	}
+}
@@ -0,0 +1,195 @@
+package aurora
+
+import (
+	"encoding/json"
+	"github.com/mmatagrin/ctxerror"
+	"sort"
+	"strings"
+)
+
+//plan of a query, as returned by EXPLAIN FORMAT=JSON (mysql) or EXPLAIN (FORMAT JSON) (postgres)
+type ExplainPlan struct {
+	Dialect Dialect
+	//json returned by the database
+	Raw      string
+	Tables   []PlanTable
+	Filesort bool
+}
+
+//access to a table in the plan
+type PlanTable struct {
+	Name string
+	//mysql access_type (ALL, ref, range...) or postgres node type (Seq Scan, Index Scan...)
+	AccessType string
+	//index used, empty when none
+	Key string
+	//estimated rows read
+	Rows int64
+}
+
+//runs EXPLAIN on the sql of the query with its parameters, the query itself is not executed
+func (aq *AuroraQuery) Explain(connexion AuroraConnexion) (*ExplainPlan, error) {
+	sqlStr := aq.GetSql()
+	dialect := aq.AuroraQueryBuilder.getDialect()
+
+	context := ctxerror.SetContext(map[string]interface{}{
+		"connexion": connexion,
+		"query": sqlStr,
+	})
+
+	if aq.err != nil {
+		return nil, context.Wrap(aq.err, "unable to render query")
+	}
+
+	explainSql := "EXPLAIN FORMAT=JSON " + sqlStr
+	if dialect == POSTGRESQL {
+		explainSql = "EXPLAIN (FORMAT JSON) " + sqlStr
+	}
+
+	res, err := performAuroraQuery(aq.name, dialect, explainSql, aq.Params(), connexion, nil)
+	if err != nil {
+		return nil, context.Wrap(err, "unable to explain query")
+	}
+
+	var raw strings.Builder
+	for _, record := range res.Records {
+		if len(record) == 0 {
+			continue
+		}
+
+		value, err := rdsFieldToValue(record[0])
+		if err != nil {
+			return nil, context.Wrap(err, "unable to read plan")
+		}
+
+		switch t := value.(type) {
+		case string:
+			raw.WriteString(t)
+		case []byte:
+			raw.Write(t)
+		}
+	}
+
+	plan, err := ParseExplainPlan(raw.String(), dialect)
+	if err != nil {
+		return nil, context.Wrap(err, "unable to parse plan")
+	}
+
+	return plan, nil
+}
+
+//parses the json of EXPLAIN FORMAT=JSON (mysql) or EXPLAIN (FORMAT JSON) (postgres)
+func ParseExplainPlan(raw string, dialect Dialect) (*ExplainPlan, error) {
+	var tree interface{}
+	if err := json.Unmarshal([]byte(raw), &tree); err != nil {
+		return nil, ctxerror.Wrap(err, "invalid json plan")
+	}
+
+	plan := &ExplainPlan{
+		Dialect: dialect,
+		Raw:     raw,
+	}
+
+	if dialect == POSTGRESQL {
+		plan.walkPostgres(tree)
+	} else {
+		plan.walkMysql(tree)
+	}
+
+	return plan, nil
+}
+
+//tables read entirely, without index
+func (ep *ExplainPlan) FullTableScans() []PlanTable {
+	tables := []PlanTable{}
+	for _, table := range ep.Tables {
+		if table.AccessType == "ALL" || table.AccessType == "Seq Scan" {
+			tables = append(tables, table)
+		}
+	}
+
+	return tables
+}
+
+func (ep *ExplainPlan) HasFullTableScan() bool {
+	return len(ep.FullTableScans()) != 0
+}
+
+//true when the rows are sorted without index (mysql filesort, postgres Sort node)
+func (ep *ExplainPlan) UsesFilesort() bool {
+	return ep.Filesort
+}
+
+func (ep *ExplainPlan) walkMysql(node interface{}) {
+	switch t := node.(type) {
+	case []interface{}:
+		for _, child := range t {
+			ep.walkMysql(child)
+		}
+	case map[string]interface{}:
+		if usingFilesort, ok := t["using_filesort"].(bool); ok && usingFilesort {
+			ep.Filesort = true
+		}
+
+		if table, ok := t["table"].(map[string]interface{}); ok {
+			ep.Tables = append(ep.Tables, PlanTable{
+				Name:       jsonString(table["table_name"]),
+				AccessType: jsonString(table["access_type"]),
+				Key:        jsonString(table["key"]),
+				Rows:       jsonInt(table["rows_examined_per_scan"]),
+			})
+		}
+
+		for _, key := range sortedKeys(t) {
+			ep.walkMysql(t[key])
+		}
+	}
+}
+
+func (ep *ExplainPlan) walkPostgres(node interface{}) {
+	switch t := node.(type) {
+	case []interface{}:
+		for _, child := range t {
+			ep.walkPostgres(child)
+		}
+	case map[string]interface{}:
+		nodeType := jsonString(t["Node Type"])
+		if nodeType == "Sort" || nodeType == "Incremental Sort" {
+			ep.Filesort = true
+		}
+
+		if relation, ok := t["Relation Name"]; ok {
+			ep.Tables = append(ep.Tables, PlanTable{
+				Name:       jsonString(relation),
+				AccessType: nodeType,
+				Key:        jsonString(t["Index Name"]),
+				Rows:       jsonInt(t["Plan Rows"]),
+			})
+		}
+
+		for _, key := range sortedKeys(t) {
+			ep.walkPostgres(t[key])
+		}
+	}
+}
+
+//the keys of the json objects are visited in order, so that the tables are always listed the same way
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func jsonString(value interface{}) string {
+	str, _ := value.(string)
+	return str
+}
+
+func jsonInt(value interface{}) int64 {
+	number, _ := value.(float64)
+	return int64(number)
+}
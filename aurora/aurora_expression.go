@@ -0,0 +1,9 @@
+package aurora
+
+//raw sql inserted as is in place of a value, ex: Values(Expression("NOW()"), Param("name"))
+type Expression string
+
+//named parameter placeholder, bound when executing the query (SetParameters, ExecuteBatch...)
+func Param(name string) Expression {
+	return Expression(":" + name)
+}
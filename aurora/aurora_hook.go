@@ -0,0 +1,128 @@
+package aurora
+
+import (
+	"log"
+	"time"
+)
+
+type Operation string
+
+const (
+	EXECUTE_STATEMENT       Operation = "ExecuteStatement"
+	BATCH_EXECUTE_STATEMENT Operation = "BatchExecuteStatement"
+	BEGIN_TRANSACTION       Operation = "BeginTransaction"
+	COMMIT_TRANSACTION      Operation = "CommitTransaction"
+	ROLLBACK_TRANSACTION    Operation = "RollbackTransaction"
+)
+
+//Data API call observed by the hooks, the fields after Start are only set for AfterQuery
+type QueryEvent struct {
+	Operation     Operation
+	Name          string  //set with AuroraQuery.Name
+	Dialect       Dialect //dialect of the query, the default dialect for the raw Perform* functions and the transactions
+	Connexion     AuroraConnexion
+	Sql           string
+	Parameters    map[string]interface{}   //ExecuteStatement parameters
+	ParameterSets []map[string]interface{} //BatchExecuteStatement parameters
+	TransactionId string                   //empty outside of a transaction
+	Start         time.Time
+	Duration      time.Duration
+	RowsAffected  int64 //0 for BatchExecuteStatement, the Data API does not count the rows affected by a batch
+	RowsReturned  int
+	Err           error
+	values        map[interface{}]interface{}
+}
+
+//stores a value for the AfterQuery of the same event, ex: a span started in BeforeQuery
+func (qe *QueryEvent) SetValue(key interface{}, value interface{}) {
+	if qe.values == nil {
+		qe.values = make(map[interface{}]interface{})
+	}
+	qe.values[key] = value
+}
+
+func (qe *QueryEvent) Value(key interface{}) interface{} {
+	return qe.values[key]
+}
+
+type QueryHook interface {
+	BeforeQuery(event *QueryEvent)
+	AfterQuery(event *QueryEvent)
+}
+
+var queryHooks []QueryHook
+
+//the hook is called around every statement, batch and transaction operation
+func AddQueryHook(hook QueryHook) {
+	queryHooks = append(queryHooks, hook)
+}
+
+func RemoveQueryHooks() {
+	queryHooks = nil
+}
+
+//calls the hooks around call, which fills the results of the event
+func instrument(event *QueryEvent, call func() error) error {
+	for _, hook := range queryHooks {
+		hook.BeforeQuery(event)
+	}
+
+	event.Start = time.Now()
+	err := call()
+	event.Duration = time.Since(event.Start)
+	event.Err = err
+
+	for i := len(queryHooks) - 1; i >= 0; i-- {
+		queryHooks[i].AfterQuery(event)
+	}
+
+	if metricsCollector != nil {
+		metricsCollector.ObserveQuery(newQueryMetric(event))
+	}
+
+	return err
+}
+
+//logs every operation with the standard log package, or Logger when set
+type LogHook struct {
+	Logger *log.Logger
+}
+
+func (lh LogHook) BeforeQuery(event *QueryEvent) {}
+
+func (lh LogHook) AfterQuery(event *QueryEvent) {
+	logEvent(lh.Logger, "", event)
+}
+
+//logs the operations taking at least Threshold
+type SlowQueryHook struct {
+	Threshold time.Duration
+	Logger    *log.Logger
+}
+
+func (sqh SlowQueryHook) BeforeQuery(event *QueryEvent) {}
+
+func (sqh SlowQueryHook) AfterQuery(event *QueryEvent) {
+	if event.Duration >= sqh.Threshold {
+		logEvent(sqh.Logger, "slow query ", event)
+	}
+}
+
+func logEvent(logger *log.Logger, prefix string, event *QueryEvent) {
+	printf := log.Printf
+	if logger != nil {
+		printf = logger.Printf
+	}
+
+	parameters := interface{}(event.Parameters)
+	if event.ParameterSets != nil {
+		parameters = event.ParameterSets
+	}
+
+	if event.Err != nil {
+		printf("sql-builder: %s%s failed after %s, transaction: %q, sql: %s, parameters: %v, error: %s", prefix, event.Operation, event.Duration, event.TransactionId, event.Sql, parameters, event.Err)
+		return
+	}
+
+	printf("sql-builder: %s%s took %s, transaction: %q, rows affected: %d, rows returned: %d, sql: %s, parameters: %v", prefix, event.Operation, event.Duration, event.TransactionId, event.RowsAffected, event.RowsReturned, event.Sql, parameters)
+}
@@ -0,0 +1,120 @@
+package aurora
+
+import (
+	"github.com/mmatagrin/ctxerror"
+	"github.com/mmatagrin/sql-builder/structs"
+	"regexp"
+	"strings"
+)
+
+//name of a table or a column, optionally qualified: "users", "users.id"
+type Identifier string
+
+var identifierRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)*$`)
+
+func (i Identifier) Validate() error {
+	if !identifierRegexp.MatchString(string(i)) {
+		return ctxerror.SetContext(map[string]interface{}{
+			"identifier": string(i),
+		}).New("invalid identifier")
+	}
+
+	return nil
+}
+
+//quoted for dialect, ex: `users`.`id` for mysql and "users"."id" for postgresql.
+//postgresql folds the unquoted names to lower case, the identifier is folded the same way so that quoting it does not
+//change the table or column it refers to: Users is quoted "users"
+func (i Identifier) Quote(dialect Dialect) string {
+	parts := strings.Split(string(i), ".")
+	for j, part := range parts {
+		parts[j] = quoteName(part, dialect)
+	}
+
+	return strings.Join(parts, ".")
+}
+
+//quotes a single name, with the postgresql case folding. the names already quoted keep their case
+func quoteName(name string, dialect Dialect) string {
+	if strings.HasPrefix(name, "`") || strings.HasPrefix(name, `"`) {
+		return dialect.quote(strings.Trim(name, "`\""))
+	}
+
+	if dialect == POSTGRESQL {
+		name = strings.ToLower(name)
+	}
+
+	return dialect.quote(name)
+}
+
+//quotes the expression when it is an identifier, other expressions (functions, sub queries, already quoted names...) are left as is
+func quoteIdentifier(expression string, dialect Dialect) string {
+	identifier := Identifier(strings.TrimSpace(expression))
+	if identifier.Validate() != nil {
+		return expression
+	}
+
+	return identifier.Quote(dialect)
+}
+
+//quotes the table and alias of a table expression such as "users", "users u" or "users AS u"
+func quoteTable(table string, dialect Dialect) string {
+	fields := strings.Fields(table)
+	switch {
+	case len(fields) == 1:
+		return quoteIdentifier(fields[0], dialect)
+	case len(fields) == 2 && Identifier(fields[0]).Validate() == nil:
+		return quoteIdentifier(fields[0], dialect) + " " + quoteIdentifier(fields[1], dialect)
+	case len(fields) == 3 && strings.ToLower(fields[1]) == "as" && Identifier(fields[0]).Validate() == nil:
+		return quoteIdentifier(fields[0], dialect) + " AS " + quoteIdentifier(fields[2], dialect)
+	default:
+		return table
+	}
+}
+
+func quoteIdentifiers(expressions []string, dialect Dialect) []string {
+	quoted := make([]string, len(expressions))
+	for i, expression := range expressions {
+		quoted[i] = quoteIdentifier(expression, dialect)
+	}
+
+	return quoted
+}
+
+func generateOrderBy(orders []structs.OrderBy, dialect Dialect) string {
+	quoted := make([]structs.OrderBy, len(orders))
+	for i, order := range orders {
+		quoted[i] = structs.OrderBy{Field: quoteIdentifier(order.Field, dialect), Order: order.Order}
+	}
+
+	return structs.JoinOrderBy(quoted, ", ")
+}
+
+//columns a user is allowed to sort on, by the name the user knows them by
+type AllowedColumns map[string]Identifier
+
+//ex: AllowColumns("name", "created_at"), use a map to expose a column under another name
+func AllowColumns(columns ...string) AllowedColumns {
+	allowed := make(AllowedColumns, len(columns))
+	for _, column := range columns {
+		allowed[column] = Identifier(column)
+	}
+
+	return allowed
+}
+
+//order on a column chosen by the user, fails when the column is not allowed
+func (ac AllowedColumns) OrderBy(field string, order structs.Order) (structs.OrderBy, error) {
+	column, ok := ac[field]
+	if !ok {
+		return structs.OrderBy{}, ctxerror.SetContext(map[string]interface{}{
+			"field": field,
+		}).New("sorting on this field is not allowed")
+	}
+
+	if err := column.Validate(); err != nil {
+		return structs.OrderBy{}, err
+	}
+
+	return structs.OrderBy{Field: string(column), Order: order}, nil
+}
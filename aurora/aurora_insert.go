@@ -1,79 +1,122 @@
 package aurora
 
 import (
-	"fmt"
 	"github.com/aws/aws-sdk-go/service/rdsdataservice"
 	"github.com/mmatagrin/ctxerror"
-	"strings"
 )
 
-//TODO replace inserts with the main query builder
-
 const (
 	INSERT_NOT_IGNORE = iota
 	INSERT_IGNORE
 	REPLACE
 )
 
-func AuroraInsert(table string, columns []string, values [][]interface{}, connexion AuroraConnexion, transactionId *string)(*rdsdataservice.ExecuteStatementOutput, error){
-	return insert(table, columns, values, connexion, INSERT_NOT_IGNORE, transactionId)
-}
-
-func AuroraInsertIgnore(table string, columns []string, values [][]interface{}, connexion AuroraConnexion, transactionId *string)(*rdsdataservice.ExecuteStatementOutput, error){
-	return insert(table, columns, values, connexion, INSERT_IGNORE, transactionId)
+type InsertResult struct {
+	NumberOfRecordsUpdated int64
+	//id generated for the first inserted row of the last statement, as LAST_INSERT_ID()
+	LastInsertId int64
+	//ids of the inserted rows, in the order of the values. mysql only returns the first one, the others are derived
+	//from it which is only correct with contiguous auto-increment allocation (innodb_autoinc_lock_mode 0 or 1).
+	//left empty for INSERT IGNORE and upserts since skipped or updated rows break the derivation
+	GeneratedIds []int64
+	//postgresql RETURNING values, one result per inserted row
+	Returning []QueryResult
 }
 
-func AuroraReplace(table string, columns []string, values [][]interface{}, connexion AuroraConnexion, transactionId *string)(*rdsdataservice.ExecuteStatementOutput, error) {
-	return insert(table, columns, values, connexion, REPLACE, transactionId)
-}
-
-func insert(table string, columns []string, values [][]interface{}, connexion AuroraConnexion, mode int, transactionId *string)(*rdsdataservice.ExecuteStatementOutput, error){
+func (aq *AuroraQuery) ExecuteInsert(connexion AuroraConnexion, transactionId *string) (*InsertResult, error) {
 	context := ctxerror.SetContext(map[string]interface{}{
-		"table": table,
-		"columns": columns,
-		"values": values,
 		"connexion": connexion,
-		"mode": mode,
-		"transactionId": fmt.Sprintf("%s", transactionId),
+		"query": aq.GetSql(),
 	})
 
-	var sqlStr string
+	if aq.QueryType != INSERT {
+		return nil, context.New("ExecuteInsert expects an insert query")
+	}
 
-	var parameters = make(map[string]interface{})
+	res, err := aq.perform(connexion, transactionId)
+	if err != nil {
+		return nil, context.Wrap(err, "unable to perform insert query")
+	}
 
-	switch mode {
-	case INSERT_NOT_IGNORE:
-		sqlStr = "INSERT INTO " + table + " "
-	case INSERT_IGNORE:
-		sqlStr = "INSERT IGNORE INTO " + table + " "
-	case REPLACE:
-		sqlStr = "REPLACE INTO " + table + " "
+	result, err := aq.newInsertResult(res)
+	if err != nil {
+		return nil, context.Wrap(err, "unable to parse insert result")
 	}
 
-	sqlStr += "(" + strings.Join(columns, ",") + ") VALUES "
+	return result, nil
+}
+
+func (aq *AuroraQuery) newInsertResult(res *rdsdataservice.ExecuteStatementOutput) (*InsertResult, error) {
+	insert := aq.AuroraQueryBuilder.query.Insert
+	result := &InsertResult{}
 
-	if len(values) == 0 {
-		sqlStr += "()"
+	if res.NumberOfRecordsUpdated != nil {
+		result.NumberOfRecordsUpdated = *res.NumberOfRecordsUpdated
 	}
 
-	for i, val := range values {
-		sqlStr += "("
+	if len(res.GeneratedFields) != 0 && res.GeneratedFields[0] != nil && res.GeneratedFields[0].LongValue != nil {
+		result.LastInsertId = *res.GeneratedFields[0].LongValue
+
+		if insert.Mode == INSERT_NOT_IGNORE && len(insert.Updates) == 0 && insert.Select == nil {
+			for i := range insert.Values {
+				result.GeneratedIds = append(result.GeneratedIds, result.LastInsertId+int64(i))
+			}
+		}
+	}
 
-		for j, fieldVal := range val{
-			sqlStr += fmt.Sprintf(":%s%d,", columns[j], i)
-			parameters[fmt.Sprintf("%s%d", columns[j], i)] = fieldVal
+	if len(insert.Returning) != 0 && res.Records != nil {
+		returning, err := ParseResults(resultFields(insert.Returning), res.Records)
+		if err != nil {
+			return nil, err
 		}
+		result.Returning = returning
+	}
+
+	return result, nil
+}
 
-		sqlStr = strings.TrimSuffix(sqlStr, ",") + "),"
+func (ir *InsertResult) merge(result *InsertResult) {
+	ir.NumberOfRecordsUpdated += result.NumberOfRecordsUpdated
+	if result.LastInsertId != 0 {
+		ir.LastInsertId = result.LastInsertId
 	}
+	ir.GeneratedIds = append(ir.GeneratedIds, result.GeneratedIds...)
+	ir.Returning = append(ir.Returning, result.Returning...)
+}
 
-	//remove the useless last ","
-	sqlStr = strings.TrimSuffix(sqlStr, ",")
+func AuroraInsert(table string, columns []string, values [][]interface{}, connexion AuroraConnexion, transactionId *string)(*rdsdataservice.ExecuteStatementOutput, error){
+	return insert(CreateQueryBuilder(), table, columns, values, connexion, INSERT_NOT_IGNORE, transactionId)
+}
+
+func AuroraInsertIgnore(table string, columns []string, values [][]interface{}, connexion AuroraConnexion, transactionId *string)(*rdsdataservice.ExecuteStatementOutput, error){
+	return insert(CreateQueryBuilder(), table, columns, values, connexion, INSERT_IGNORE, transactionId)
+}
+
+//REPLACE deletes and reinserts the conflicting rows, use the query builder OnDuplicateKeyUpdate to update them in place
+func AuroraReplace(table string, columns []string, values [][]interface{}, connexion AuroraConnexion, transactionId *string)(*rdsdataservice.ExecuteStatementOutput, error) {
+	return insert(CreateQueryBuilder(), table, columns, values, connexion, REPLACE, transactionId)
+}
+
+func insert(builder *AuroraQueryBuilder, table string, columns []string, values [][]interface{}, connexion AuroraConnexion, mode int, transactionId *string)(*rdsdataservice.ExecuteStatementOutput, error){
+	context := ctxerror.SetContext(map[string]interface{}{
+		"table": table,
+		"columns": columns,
+		"values": redactRows(columns, values),
+		"connexion": connexion,
+		"mode": mode,
+		"transactionId": transactionId,
+	})
+
+	builder.Into(table).InsertMode(mode).Columns(columns...)
+	for _, row := range values {
+		builder.Values(row...)
+	}
+	query := builder.GetQuery()
 
-	res, err := PerformAuroraQuery(sqlStr, parameters, connexion, transactionId)
+	res, err := query.perform(connexion, transactionId)
 	if err != nil {
-		context.AddContext("sql_query", sqlStr)
-		context.AddContext("sql_query_parameters", parameters)
+		context.AddContext("sql_query", query.GetSql())
+		context.AddContext("sql_query_parameters", RedactParameters(query.Params()))
 		return nil, context.Wrap(err, "unable to perform insert query")
 	}
 
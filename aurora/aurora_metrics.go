@@ -0,0 +1,74 @@
+package aurora
+
+import (
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"strings"
+	"time"
+)
+
+type ErrorClass string
+
+const (
+	NO_ERROR             ErrorClass = ""
+	COLD_START           ErrorClass = "cold_start"
+	THROTTLING           ErrorClass = "throttling"
+	CONSTRAINT_VIOLATION ErrorClass = "constraint_violation"
+	TIMEOUT              ErrorClass = "timeout"
+	OTHER_ERROR          ErrorClass = "other"
+)
+
+type QueryMetric struct {
+	Name         string //set with AuroraQuery.Name, empty for unnamed queries
+	Operation    Operation
+	Duration     time.Duration
+	ErrorClass   ErrorClass
+	RowsAffected int64 //0 for BatchExecuteStatement, see QueryEvent
+	RowsReturned int
+}
+
+type MetricsCollector interface {
+	ObserveQuery(metric QueryMetric)
+}
+
+var metricsCollector MetricsCollector
+
+//the collector observes every statement, batch and transaction operation, nil disables the metrics
+func SetMetricsCollector(collector MetricsCollector) {
+	metricsCollector = collector
+}
+
+func newQueryMetric(event *QueryEvent) QueryMetric {
+	return QueryMetric{
+		Name:         event.Name,
+		Operation:    event.Operation,
+		Duration:     event.Duration,
+		ErrorClass:   ClassifyError(event.Err),
+		RowsAffected: event.RowsAffected,
+		RowsReturned: event.RowsReturned,
+	}
+}
+
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return NO_ERROR
+	}
+
+	message := err.Error()
+	if awsErr, ok := err.(awserr.Error); ok {
+		message = awsErr.Code() + " " + awsErr.Message()
+	}
+
+	switch {
+	case strings.Contains(message, "Communications link failure"), strings.Contains(message, "DatabaseResumingException"):
+		return COLD_START
+	case strings.Contains(message, "Throttling"), strings.Contains(message, "TooManyRequests"), strings.Contains(message, "Rate exceeded"):
+		return THROTTLING
+	case strings.Contains(message, "Duplicate entry"), strings.Contains(message, "foreign key constraint"),
+		strings.Contains(message, "cannot be null"), strings.Contains(message, "violates"):
+		return CONSTRAINT_VIOLATION
+	case strings.Contains(message, "StatementTimeoutException"), strings.Contains(message, "timeout"):
+		return TIMEOUT
+	default:
+		return OTHER_ERROR
+	}
+}
@@ -1,6 +1,8 @@
 package aurora
 
 import (
+	"fmt"
+	"github.com/aws/aws-sdk-go/service/rdsdataservice"
 	"github.com/mmatagrin/ctxerror"
 	"github.com/mmatagrin/sql-builder/structs"
 	"strconv"
@@ -13,29 +15,122 @@ const (
 	SELECT QueryType  =  "select"
 	UPDATE = "update"
 	DELETE = "delete"
+	INSERT = "insert"
 )
 type AuroraQuery struct {
 	QueryType          QueryType
 	AuroraQueryBuilder AuroraQueryBuilder
 	SqlStr             string
 	parameters         map[string]interface{}
+	//parameters generated while preparing the sql (inserted values...)
+	boundParameters    map[string]interface{}
+	err                error
+	name               string
 }
 
 func (aq *AuroraQuery) GetSql() string {
 	if len(aq.SqlStr) == 0 {
+		aq.boundParameters = make(map[string]interface{})
 		aq.SqlStr = aq.PrepareSql(aq.AuroraQueryBuilder.query)
 	}
 
 	return aq.SqlStr
 }
 
+//returns the parameters sent with the query: the ones generated by the builder and the ones given to SetParameters.
+//the parameters generated by the builder are never overridden, check fails when they are given
+func (aq *AuroraQuery) Params() map[string]interface{} {
+	parameters := aq.givenParameters()
+	for key, value := range aq.boundParameters {
+		parameters[key] = value
+	}
+
+	return parameters
+}
+
+//parameters given to the builders and to SetParameters
+func (aq *AuroraQuery) givenParameters() map[string]interface{} {
+	aq.GetSql()
+
+	parameters := make(map[string]interface{}, len(aq.boundParameters)+len(aq.parameters))
+	collectParameters(aq.AuroraQueryBuilder.query, parameters)
+	for key, value := range aq.parameters {
+		parameters[key] = value
+	}
+
+	return parameters
+}
+
+//the parameters generated by the builder (inserted values, tenant...) can not be given by the caller
+func (aq *AuroraQuery) checkParameters(parameters map[string]interface{}) error {
+	for key := range parameters {
+		if _, bound := aq.boundParameters[key]; bound {
+			return ctxerror.SetContext(map[string]interface{}{
+				"parameter": key,
+			}).New("parameter " + key + " is generated by the query builder and can not be given")
+		}
+	}
+
+	return nil
+}
+
+//parameters given to the builders of the query, its unions and its INSERT ... SELECT
+func collectParameters(query structs.Query, parameters map[string]interface{}) {
+	for key, value := range query.Parameters {
+		parameters[key] = value
+	}
+
+	for _, union := range query.Union {
+		collectParameters(union, parameters)
+	}
+
+	if query.Insert.Select != nil {
+		collectParameters(*query.Insert.Select, parameters)
+	}
+}
+
+func (aq *AuroraQuery) bindParameter(name string, value interface{}) string {
+	if aq.boundParameters == nil {
+		aq.boundParameters = make(map[string]interface{})
+	}
+	aq.boundParameters[name] = value
+	return ":" + name
+}
+
+func (aq *AuroraQuery) perform(connexion AuroraConnexion, transactionId *string) (*rdsdataservice.ExecuteStatementOutput, error) {
+	sqlStr := aq.GetSql()
+	if err := aq.check(); err != nil {
+		return nil, err
+	}
+
+	return performAuroraQuery(aq.name, aq.AuroraQueryBuilder.getDialect(), sqlStr, aq.Params(), connexion, transactionId)
+}
+
+//errors preventing the query from being executed
+func (aq *AuroraQuery) check() error {
+	aq.GetSql()
+	if aq.err != nil {
+		return aq.err
+	}
+
+	if err := aq.checkParameters(aq.givenParameters()); err != nil {
+		return err
+	}
+
+	if err := aq.checkStrictMode(); err != nil {
+		return err
+	}
+
+	return aq.checkSafeMode()
+}
+
 func (aq *AuroraQuery) GetResults(connexion AuroraConnexion, transactionId *string) ([]QueryResult, error) {
 	context := ctxerror.SetContext(map[string]interface{}{
 		"connexion": connexion,
 		"query": aq.GetSql(),
 	})
 
-	res, err := PerformAuroraQuery(aq.GetSql(), aq.parameters, connexion, transactionId)
+	res, err := aq.perform(connexion, transactionId)
 
 	if err != nil{
 		return nil, context.Wrap(err, "unable to perform query")
@@ -46,21 +141,26 @@ func (aq *AuroraQuery) GetResults(connexion AuroraConnexion, transactionId *stri
 	}
 
 	if res.Records != nil {
-		fields := []string{}
-		for _, field := range aq.AuroraQueryBuilder.query.Select {
-			splitField := strings.Split(strings.ToLower(field), " as ")
-			if len(splitField) > 1 {
-				fields = append(fields, splitField[1])
-				continue
-			}
+		return ParseResults(resultFields(aq.AuroraQueryBuilder.query.Select), res.Records)
+	}
 
-			fields = append(fields, field)
+	return []QueryResult{}, nil
+}
+
+//names of the returned fields, using the alias when the field has one
+func resultFields(selectFields []string) []string {
+	fields := []string{}
+	for _, field := range selectFields {
+		splitField := strings.Split(strings.ToLower(field), " as ")
+		if len(splitField) > 1 {
+			fields = append(fields, splitField[1])
+			continue
 		}
 
-		return ParseResults(fields, res.Records)
+		fields = append(fields, field)
 	}
 
-	return []QueryResult{}, nil
+	return fields
 }
 
 func (aq *AuroraQuery) Execute (connexion AuroraConnexion, transactionId *string) (int64, error){
@@ -70,7 +170,11 @@ func (aq *AuroraQuery) Execute (connexion AuroraConnexion, transactionId *string
 		"query": aq.GetSql(),
 	})
 
-	res, err := PerformAuroraQuery(aq.GetSql(), aq.parameters, connexion, transactionId)
+	if aq.AuroraQueryBuilder.maxAffectedRows > 0 {
+		return aq.executeWithMaxAffectedRows(connexion, transactionId)
+	}
+
+	res, err := aq.perform(connexion, transactionId)
 	if err != nil {
 		return 0, context.Wrap(err, "unable to execute query")
 	}
@@ -84,48 +188,33 @@ func (aq *AuroraQuery) Execute (connexion AuroraConnexion, transactionId *string
 
 func (aq *AuroraQuery) PrepareSql(query structs.Query) string {
 	var sqlStr = ""
+	dialect := aq.AuroraQueryBuilder.getDialect()
+	query = aq.withTenant(query)
 
 	switch aq.QueryType {
 	case DELETE:
-		sqlStr += generateDeleteExpression(query)
+		return aq.generateDeleteExpression(query, dialect)
+	case INSERT:
+		return aq.generateInsertExpression(query.Insert, dialect)
+	case UPDATE:
+		return aq.generateUpdateExpression(withSoftDeletedExcluded(query, dialect), dialect)
 	default:
-		sqlStr += generateSelectExpression(query)
+		query = withSoftDeleteFilter(query, dialect)
+		sqlStr += generateSelectExpression(query, dialect)
 	}
 
-
-	if len(query.Where) != 0 || len(query.WhereQueryParameters) != 0 {
-		var indexShared = 0
-		for _, expression := range query.Where {
-			sqlStr += generateWhereClause(expression, indexShared)
-			indexShared++
-		}
-
-		for _, expression := range query.OrWhere {
-			sqlStr += generateOrClause(expression)
-			indexShared++
-		}
-
-		for _, queryParameter := range query.WhereQueryParameters {
-			sqlStr += generateWhereParanthesis(queryParameter, "AND", indexShared)
-			indexShared++
-		}
-
-		for _, queryParameter := range query.OrWhereQueryParameters {
-			sqlStr += generateWhereParanthesis(queryParameter, "OR", indexShared)
-			indexShared++
-		}
-	}
+	sqlStr += generateWhere(query)
 
 	if len(query.GroupBy) != 0 {
-		sqlStr += "GROUP BY " + strings.Join(query.GroupBy, ", ") + " "
+		sqlStr += "GROUP BY " + strings.Join(quoteIdentifiers(query.GroupBy, dialect), ", ") + " "
 	}
 
 	if len(query.Order) != 0 {
-		sqlStr += "ORDER BY " + structs.JoinOrderBy(query.Order, ", ") + " "
+		sqlStr += "ORDER BY " + generateOrderBy(query.Order, dialect) + " "
 	}
 
 	if query.Limit[1] != 0 {
-		sqlStr += "LIMIT " + strconv.Itoa(query.Limit[0]) + "," + strconv.Itoa(query.Limit[1])
+		sqlStr += dialect.limit(query.Limit[0], query.Limit[1])
 	}
 
 	if len(query.Having) != 0 || len(query.HavingQueryParameters) != 0 {
@@ -160,25 +249,169 @@ func (aq *AuroraQuery) PrepareSql(query structs.Query) string {
 	return sqlStr
 }
 
-func generateSelectExpression(query structs.Query)string{
+func generateSelectExpression(query structs.Query, dialect Dialect)string{
 	var sqlStr string
 	sqlStr = `SELECT ` + strings.Join(query.Select, ",") +
-		` FROM ` + query.From + ` `
+		` FROM ` + quoteTable(query.From, dialect) + ` `
 
 	if len(query.Join) != 0 {
 		for _, join := range query.Join {
-			sqlStr += generateJoinString(join)
+			sqlStr += generateJoinString(join, dialect)
 		}
 	}
 
 	return sqlStr
 }
 
-func generateDeleteExpression(query structs.Query) string{
-	return "DELETE FROM " + query.Delete + " "
+func generateWhere(query structs.Query) string {
+	var sqlStr string
+
+	if len(query.Where) != 0 || len(query.WhereQueryParameters) != 0 || len(query.OrWhere) != 0 || len(query.OrWhereQueryParameters) != 0 {
+		var indexShared = 0
+		for _, expression := range query.Where {
+			sqlStr += generateWhereClause(expression, indexShared)
+			indexShared++
+		}
+
+		for _, expression := range query.OrWhere {
+			if indexShared == 0 {
+				sqlStr += generateWhereClause(expression, indexShared)
+			} else {
+				sqlStr += generateOrClause(expression)
+			}
+			indexShared++
+		}
+
+		for _, queryParameter := range query.WhereQueryParameters {
+			sqlStr += generateWhereParanthesis(queryParameter, "AND", indexShared)
+			indexShared++
+		}
+
+		for _, queryParameter := range query.OrWhereQueryParameters {
+			sqlStr += generateWhereParanthesis(queryParameter, "OR", indexShared)
+			indexShared++
+		}
+	}
+
+	return sqlStr
+}
+
+func (aq *AuroraQuery) generateUpdateExpression(query structs.Query, dialect Dialect) string {
+	assignments := make([]string, len(query.Update.Set))
+	for i, assignment := range query.Update.Set {
+		expression := assignment.Expression
+		if expression == "" {
+			expression = aq.bindParameter(fmt.Sprintf("set_%d", i), sensitiveForColumn(assignment.Column, assignment.Value))
+		}
+
+		if assignment.Column == "" {
+			assignments[i] = expression
+		} else {
+			assignments[i] = quoteIdentifier(assignment.Column, dialect) + " = " + expression
+		}
+	}
+
+	if len(assignments) == 0 {
+		aq.err = ctxerror.New("update has no column to set")
+	}
+
+	if dialect == POSTGRESQL {
+		sqlStr := "UPDATE " + quoteTable(query.Update.Table, dialect) + " SET " + strings.Join(assignments, ", ") + " "
+		if len(query.Join) != 0 {
+			sqlStr += "FROM " + aq.joinsAsConditions(&query, dialect, "updates") + " "
+		}
+
+		return sqlStr + generateWhere(query) + aq.generateOrderAndLimit(query, dialect, "updates")
+	}
+
+	sqlStr := "UPDATE " + quoteTable(query.Update.Table, dialect) + " "
+	for _, join := range query.Join {
+		sqlStr += generateJoinString(join, dialect)
+	}
+	sqlStr += "SET " + strings.Join(assignments, ", ") + " " + generateWhere(query)
+
+	return sqlStr + aq.generateOrderAndLimit(query, dialect, "updates")
+}
+
+//DELETE FROM table for a single table, DELETE table FROM table JOIN ... (mysql) or DELETE FROM table USING ... (postgresql) with joins
+func (aq *AuroraQuery) generateDeleteExpression(query structs.Query, dialect Dialect) string {
+	sqlStr := "DELETE FROM " + quoteTable(query.Delete, dialect) + " "
+
+	if len(query.Join) != 0 {
+		if dialect == POSTGRESQL {
+			sqlStr += "USING " + aq.joinsAsConditions(&query, dialect, "deletes") + " "
+		} else {
+			sqlStr = "DELETE " + quoteIdentifier(tableAlias(query.Delete), dialect) + " FROM " + quoteTable(query.Delete, dialect) + " "
+			for _, join := range query.Join {
+				sqlStr += generateJoinString(join, dialect)
+			}
+		}
+	}
+
+	return sqlStr + generateWhere(query) + aq.generateOrderAndLimit(query, dialect, "deletes")
+}
+
+//postgresql updates and deletes list the joined tables in FROM / USING and AND the join conditions to the where conditions
+func (aq *AuroraQuery) joinsAsConditions(query *structs.Query, dialect Dialect, statement string) string {
+	tables := make([]string, len(query.Join))
+	var conditions []string
+	for i, join := range query.Join {
+		if join.Type != "inner" {
+			aq.err = ctxerror.New(join.Type + " join is not supported by " + string(dialect) + " " + statement)
+		}
+		tables[i] = quoteTable(join.SrcTable, dialect)
+		conditions = append(conditions, generateJoinCondition(join, dialect))
+		conditions = append(conditions, join.Conditions...)
+	}
+
+	//the user's conditions are grouped, an OrWhere must not bypass the join conditions and cross join the tables
+	*query = withConditions(*query, conditions...)
+	return strings.Join(tables, ", ")
 }
 
-func generateJoinString(join structs.Join) string {
+//ORDER BY and LIMIT of updates and deletes, only supported by mysql single table statements
+func (aq *AuroraQuery) generateOrderAndLimit(query structs.Query, dialect Dialect, statement string) string {
+	if len(query.Order) == 0 && query.Limit[1] == 0 {
+		return ""
+	}
+
+	if dialect == POSTGRESQL {
+		aq.err = ctxerror.New("ORDER BY and LIMIT are not supported by " + string(dialect) + " " + statement)
+	} else if len(query.Join) != 0 {
+		aq.err = ctxerror.New("ORDER BY and LIMIT are not supported by multi-table " + statement)
+	}
+
+	var sqlStr string
+	if len(query.Order) != 0 {
+		sqlStr += "ORDER BY " + generateOrderBy(query.Order, dialect) + " "
+	}
+
+	if query.Limit[1] != 0 {
+		if query.Limit[0] != 0 {
+			aq.err = ctxerror.New("LIMIT offset is not supported by " + statement)
+		}
+		sqlStr += "LIMIT " + strconv.Itoa(query.Limit[1]) + " "
+	}
+
+	return sqlStr
+}
+
+//alias of a table expression such as "users", "users u" or "users AS u"
+func tableAlias(table string) string {
+	//sub query: "(SELECT ...) AS t"
+	if index := strings.LastIndex(table, ")"); index != -1 {
+		table = table[index+1:]
+	}
+
+	fields := strings.Fields(table)
+	if len(fields) == 0 {
+		return table
+	}
+
+	return fields[len(fields)-1]
+}
+
+func generateJoinString(join structs.Join, dialect Dialect) string {
 	joinMethod := ""
 	switch join.Type {
 	case "left":
@@ -191,20 +424,80 @@ func generateJoinString(join structs.Join) string {
 		joinMethod = "JOIN "
 	}
 
-	var joinSrcAlias = join.SrcTable
-	if strings.Contains(strings.ToLower(join.SrcTable), " as") {
-		joinSrcAlias = strings.Replace(join.SrcTable[strings.LastIndex(join.SrcTable, " as")+3:], " ", "", -2)
-	} else if strings.Contains(join.SrcTable, ")") {
-		joinSrcAlias = strings.Replace(join.SrcTable[strings.Index(join.SrcTable, ")")+1:], " ", "", -1)
+	sqlStr := joinMethod + quoteTable(join.SrcTable, dialect) + " ON " + generateJoinCondition(join, dialect) + " "
+	for _, condition := range join.Conditions {
+		sqlStr += "AND " + condition + " "
 	}
 
-	var joinTargetAlias = join.TargetTable
-	if strings.Contains(strings.ToLower(join.TargetTable), " as") {
-		joinTargetAlias = strings.Replace(join.TargetTable[strings.LastIndex(join.TargetTable, " as")+3:], " ", "", -2)
-	} else if strings.Contains(join.TargetTable, ")") {
-		joinTargetAlias = strings.Replace(join.TargetTable[strings.Index(join.TargetTable, ")")+1:], " ", "", -1)
+	return sqlStr
+}
+
+func generateJoinCondition(join structs.Join, dialect Dialect) string {
+	joinSrcAlias := tableAlias(join.SrcTable)
+	joinTargetAlias := tableAlias(join.TargetTable)
+
+	return quoteName(joinSrcAlias, dialect) + "." + quoteName(join.PrimaryKey, dialect) + " = " + quoteName(joinTargetAlias, dialect) + "." + quoteName(join.ForeignKey, dialect)
+}
+
+func (aq *AuroraQuery) generateInsertExpression(insert structs.Insert, dialect Dialect) string {
+	if insert.Mode == REPLACE && dialect == POSTGRESQL {
+		aq.err = ctxerror.New("REPLACE is not supported by " + string(dialect))
 	}
-	return joinMethod + join.SrcTable + " ON " + "`" + joinSrcAlias + "`.`" + join.PrimaryKey + "` = `" + joinTargetAlias + "`.`" + join.ForeignKey + "` "
+
+	if insert.Mode == REPLACE && len(insert.Updates) != 0 {
+		aq.err = ctxerror.New("REPLACE can not update on duplicate key, use INSERT_NOT_IGNORE with OnDuplicateKeyUpdate")
+	}
+
+	if len(insert.Returning) != 0 && dialect != POSTGRESQL {
+		aq.err = ctxerror.New("RETURNING is not supported by " + string(dialect))
+	}
+
+	if len(insert.Updates) != 0 && len(insert.OnConflict) == 0 && dialect == POSTGRESQL {
+		aq.err = ctxerror.New("OnConflict is required to update on conflict with " + string(dialect))
+	}
+
+	sqlStr := dialect.insertVerb(insert.Mode) + quoteTable(insert.Into, dialect) + " (" + strings.Join(quoteIdentifiers(insert.Columns, dialect), ",") + ") "
+
+	if insert.Select != nil {
+		selectQuery := AuroraQuery{
+			QueryType:          SELECT,
+			AuroraQueryBuilder: AuroraQueryBuilder{query: *insert.Select, dialect: dialect, tenant: aq.AuroraQueryBuilder.tenant},
+			boundParameters:    aq.boundParameters,
+		}
+		sqlStr += strings.TrimSuffix(selectQuery.PrepareSql(*insert.Select), " ")
+		if selectQuery.err != nil {
+			aq.err = selectQuery.err
+		}
+
+		return sqlStr + dialect.insertSuffix(insert)
+	}
+
+	sqlStr += "VALUES "
+
+	if len(insert.Values) == 0 {
+		sqlStr += "()"
+	}
+
+	rows := make([]string, len(insert.Values))
+	for i, row := range insert.Values {
+		placeholders := make([]string, len(row))
+		for j, value := range row {
+			if expression, ok := value.(Expression); ok {
+				placeholders[j] = string(expression)
+				continue
+			}
+
+			//parameters are named by position, column names can contain characters not allowed in a parameter name
+			var column string
+			if j < len(insert.Columns) {
+				column = insert.Columns[j]
+			}
+			placeholders[j] = aq.bindParameter(fmt.Sprintf("insert_%d_%d", i, j), sensitiveForColumn(column, value))
+		}
+		rows[i] = "(" + strings.Join(placeholders, ",") + ")"
+	}
+
+	return sqlStr + strings.Join(rows, ",") + dialect.insertSuffix(insert)
 }
 
 func generateWhereClause(expression string, index int) string {
@@ -285,6 +578,12 @@ func generateHavingParanthesis(queryParameters structs.QueryParameter, comparato
 	return generateConditionInParanthesis(queryParameters, "HAVING", comparator, indexGlob, false)
 }
 
+//labels the query in the hooks and metrics, ex: "get_user_by_email"
+func (aq *AuroraQuery) Name(name string) *AuroraQuery {
+	aq.name = name
+	return aq
+}
+
 func (aq *AuroraQuery) SetParameters(parameters map[string]interface{}) *AuroraQuery {
 	aq.parameters = parameters
 	return aq
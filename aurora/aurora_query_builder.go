@@ -2,10 +2,18 @@ package aurora
 
 import (
 	"github.com/mmatagrin/sql-builder/structs"
+	"reflect"
 )
 
 type AuroraQueryBuilder struct {
-	query structs.Query
+	query               structs.Query
+	dialect             Dialect
+	allowFullTable      bool
+	maxAffectedRows     int64
+	scopes              []Scope
+	withoutGlobalScopes bool
+	tenant              tenantBinding
+	strict              bool
 }
 
 func CreateQueryBuilder() *AuroraQueryBuilder {
@@ -108,22 +116,29 @@ func (aqb *AuroraQueryBuilder) GroupeBy(fields ...string) *AuroraQueryBuilder {
 }
 
 func (aqb *AuroraQueryBuilder) GetQuery() *AuroraQuery {
+	builder := aqb.withScopes()
+
 	var queryType QueryType
-	if len(aqb.query.Select) > 0 {
+	if len(builder.query.Select) > 0 {
 		queryType = SELECT
-	} else if aqb.query.Delete != "" {
+	} else if builder.query.Delete != "" {
 		queryType = DELETE
+	} else if builder.query.Insert.Into != "" {
+		queryType = INSERT
+	} else if builder.query.Update.Table != "" {
+		queryType = UPDATE
 	}
-	return &AuroraQuery{AuroraQueryBuilder: *aqb, QueryType: queryType}
+	return &AuroraQuery{AuroraQueryBuilder: builder, QueryType: queryType}
 }
 
+//the scopes of builder are applied when Union is called
 func (aqb *AuroraQueryBuilder) Union(builder AuroraQueryBuilder) *AuroraQueryBuilder {
-	aqb.query.Union = append(aqb.query.Union, builder.query)
+	aqb.query.Union = append(aqb.query.Union, aqb.memberQuery(builder, "Union"))
 	return aqb
 }
 
 func (aqb *AuroraQueryBuilder) UnionCallback(callback func(builder AuroraQueryBuilder) AuroraQueryBuilder) *AuroraQueryBuilder {
-	aqb.query.Union = append(aqb.query.Union, callback(AuroraQueryBuilder{}).query)
+	aqb.query.Union = append(aqb.query.Union, aqb.memberQuery(callback(AuroraQueryBuilder{}), "UnionCallback"))
 	return aqb
 }
 
@@ -144,3 +159,159 @@ func (aqb *AuroraQueryBuilder) Limit(limit ...int) *AuroraQueryBuilder {
 	}
 	return aqb
 }
+
+func (aqb *AuroraQueryBuilder) Dialect(dialect Dialect) *AuroraQueryBuilder {
+	aqb.dialect = dialect
+	return aqb
+}
+
+func (aqb *AuroraQueryBuilder) getDialect() Dialect {
+	if aqb.dialect == "" {
+		return defaultDialect
+	}
+
+	return aqb.dialect
+}
+
+func (aqb *AuroraQueryBuilder) Into(table string) *AuroraQueryBuilder {
+	aqb.query.Insert.Into = table
+	return aqb
+}
+
+//mode is one of INSERT_NOT_IGNORE (default), INSERT_IGNORE or REPLACE. REPLACE can not be combined with OnDuplicateKeyUpdate
+func (aqb *AuroraQueryBuilder) InsertMode(mode int) *AuroraQueryBuilder {
+	aqb.query.Insert.Mode = mode
+	return aqb
+}
+
+func (aqb *AuroraQueryBuilder) Columns(columns ...string) *AuroraQueryBuilder {
+	aqb.query.Insert.Columns = columns
+	return aqb
+}
+
+//adds one row to insert, values are in the same order as the columns
+func (aqb *AuroraQueryBuilder) Values(values ...interface{}) *AuroraQueryBuilder {
+	aqb.query.Insert.Values = append(aqb.query.Insert.Values, values)
+	return aqb
+}
+
+//adds rows to insert from map[string]interface{} or structs (fields are named by their `db` tag).
+//when Columns was not called, the columns are taken from the first row
+func (aqb *AuroraQueryBuilder) Rows(rows ...interface{}) *AuroraQueryBuilder {
+	for _, row := range rows {
+		if len(aqb.query.Insert.Columns) == 0 {
+			columns, _, ok := rowToColumns(row)
+			if !ok {
+				panic("Err, function Rows expected maps or structs")
+			}
+			aqb.query.Insert.Columns = columns
+		}
+
+		values, ok := rowValues(row, aqb.query.Insert.Columns)
+		if !ok {
+			panic("Err, function Rows expected maps or structs containing all the columns")
+		}
+		aqb.query.Insert.Values = append(aqb.query.Insert.Values, values)
+	}
+	return aqb
+}
+
+//on duplicate key, sets the columns to the value that was being inserted
+func (aqb *AuroraQueryBuilder) OnDuplicateKeyUpdate(columns ...string) *AuroraQueryBuilder {
+	for _, column := range columns {
+		aqb.query.Insert.Updates = append(aqb.query.Insert.Updates, structs.Assignment{Column: column})
+	}
+	return aqb
+}
+
+//on duplicate key, sets the column to expression, ex: OnDuplicateKeyUpdateExpr("counter", "counter + 1")
+func (aqb *AuroraQueryBuilder) OnDuplicateKeyUpdateExpr(column string, expression string) *AuroraQueryBuilder {
+	aqb.query.Insert.Updates = append(aqb.query.Insert.Updates, structs.Assignment{Column: column, Expression: expression})
+	return aqb
+}
+
+//conflict target of the upsert, required by postgresql and ignored by mysql
+func (aqb *AuroraQueryBuilder) OnConflict(columns ...string) *AuroraQueryBuilder {
+	aqb.query.Insert.OnConflict = columns
+	return aqb
+}
+
+//mysql only (8.0.19+), the inserted row can be referenced as alias.column in the updates instead of VALUES(column)
+func (aqb *AuroraQueryBuilder) InsertAlias(alias string) *AuroraQueryBuilder {
+	aqb.query.Insert.Alias = alias
+	return aqb
+}
+
+//parameters of the query, carried along when the builder is used in a union or an INSERT ... SELECT
+func (aqb *AuroraQueryBuilder) SetParameters(parameters map[string]interface{}) *AuroraQueryBuilder {
+	aqb.query.Parameters = parameters
+	return aqb
+}
+
+//INSERT INTO table (columns) SELECT ..., the values are the rows selected by builder. its scopes are applied when
+//InsertSelect is called
+func (aqb *AuroraQueryBuilder) InsertSelect(builder AuroraQueryBuilder) *AuroraQueryBuilder {
+	selectQuery := aqb.memberQuery(builder, "InsertSelect")
+	aqb.query.Insert.Select = &selectQuery
+	return aqb
+}
+
+func (aqb *AuroraQueryBuilder) InsertSelectCallback(callback func(builder AuroraQueryBuilder) AuroraQueryBuilder) *AuroraQueryBuilder {
+	selectQuery := aqb.memberQuery(callback(AuroraQueryBuilder{}), "InsertSelectCallback")
+	aqb.query.Insert.Select = &selectQuery
+	return aqb
+}
+
+//postgresql only, the inserted rows columns are returned in InsertResult.Returning
+func (aqb *AuroraQueryBuilder) Returning(columns ...string) *AuroraQueryBuilder {
+	aqb.query.Insert.Returning = columns
+	return aqb
+}
+
+//UPDATE table, joined tables are updated with a multi-table UPDATE for mysql and UPDATE ... FROM for postgresql
+func (aqb *AuroraQueryBuilder) Update(table string) *AuroraQueryBuilder {
+	aqb.query.Update.Table = table
+	return aqb
+}
+
+//sets column to value, the value is sent as a parameter unless it is an Expression
+func (aqb *AuroraQueryBuilder) Set(column string, value interface{}) *AuroraQueryBuilder {
+	if expression, ok := value.(Expression); ok {
+		return aqb.SetExpr(column, string(expression))
+	}
+
+	aqb.query.Update.Set = append(aqb.query.Update.Set, structs.Assignment{Column: column, Value: value})
+	return aqb
+}
+
+//sets column to a raw sql expression, ex: SetExpr("counter", "counter + 1")
+func (aqb *AuroraQueryBuilder) SetExpr(column string, expression string) *AuroraQueryBuilder {
+	aqb.query.Update.Set = append(aqb.query.Update.Set, structs.Assignment{Column: column, Expression: expression})
+	return aqb
+}
+
+//sets the fields of a struct (or a map[string]interface{}), fields are named by their `db` tag.
+//when original is not nil, only the fields whose value differs from original are set
+func (aqb *AuroraQueryBuilder) SetFromStruct(value interface{}, original interface{}) *AuroraQueryBuilder {
+	columns, values, ok := rowToColumns(value)
+	if !ok {
+		panic("Err, function SetFromStruct expected a map or a struct")
+	}
+
+	var originalValues []interface{}
+	if original != nil {
+		originalValues, ok = rowValues(original, columns)
+		if !ok {
+			panic("Err, function SetFromStruct expected an original of the same type as value")
+		}
+	}
+
+	for i, column := range columns {
+		if originalValues != nil && reflect.DeepEqual(values[i], originalValues[i]) {
+			continue
+		}
+
+		aqb.Set(column, values[i])
+	}
+	return aqb
+}
@@ -0,0 +1,103 @@
+package aurora
+
+import (
+	"regexp"
+)
+
+const REDACTED = "[REDACTED]"
+
+//value hidden from the error context, hooks and logs, it is still sent to the database
+type Sensitive struct {
+	Value interface{}
+}
+
+type RedactionPolicy struct {
+	//parameters whose name matches one of the patterns are redacted
+	ParameterNames []*regexp.Regexp
+	//values bound by the builders for these columns (inserted values, Set...) are redacted
+	Columns []string
+}
+
+var redactionPolicy = RedactionPolicy{
+	ParameterNames: []*regexp.Regexp{regexp.MustCompile(`(?i)password|secret|token`)},
+}
+
+//replaces the default policy, which redacts the parameters named like password, secret or token
+func SetRedactionPolicy(policy RedactionPolicy) {
+	redactionPolicy = policy
+}
+
+//copy of parameters safe to be logged
+func RedactParameters(parameters map[string]interface{}) map[string]interface{} {
+	if parameters == nil {
+		return nil
+	}
+
+	redacted := make(map[string]interface{}, len(parameters))
+	for key, value := range parameters {
+		redacted[key] = redactParameter(key, value)
+	}
+
+	return redacted
+}
+
+func redactParameter(name string, value interface{}) interface{} {
+	for _, pattern := range redactionPolicy.ParameterNames {
+		if pattern.MatchString(name) {
+			return REDACTED
+		}
+	}
+
+	return redactValue(value)
+}
+
+func redactParameterSets(parameterSets []map[string]interface{}) []map[string]interface{} {
+	if parameterSets == nil {
+		return nil
+	}
+
+	redacted := make([]map[string]interface{}, len(parameterSets))
+	for i, parameters := range parameterSets {
+		redacted[i] = RedactParameters(parameters)
+	}
+
+	return redacted
+}
+
+func redactValue(value interface{}) interface{} {
+	switch value.(type) {
+	case Sensitive, *Sensitive:
+		return REDACTED
+	}
+
+	return value
+}
+
+//copy of rows safe to be logged, the values of the columns redacted by the policy are hidden too
+func redactRows(columns []string, rows [][]interface{}) [][]interface{} {
+	redacted := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		redacted[i] = make([]interface{}, len(row))
+		for j, value := range row {
+			if j < len(columns) {
+				value = sensitiveForColumn(columns[j], value)
+			}
+			redacted[i][j] = redactValue(value)
+		}
+	}
+
+	return redacted
+}
+
+//value marked as Sensitive when the policy redacts column
+func sensitiveForColumn(column string, value interface{}) interface{} {
+	for _, redactedColumn := range redactionPolicy.Columns {
+		if redactedColumn == column {
+			if _, ok := value.(Sensitive); !ok {
+				return Sensitive{Value: value}
+			}
+		}
+	}
+
+	return value
+}
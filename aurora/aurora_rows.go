@@ -0,0 +1,229 @@
+package aurora
+
+import (
+	"github.com/aws/aws-sdk-go/service/rdsdataservice"
+	"github.com/mmatagrin/ctxerror"
+	"reflect"
+)
+
+//iterator over the records of a query: call Next, then Scan, ScanStruct or Result for each row, and check Err at the end.
+//the fields are converted only when the current row is read, and the records already read are released
+type Rows struct {
+	fields  []string
+	records [][]*rdsdataservice.Field
+	index   int
+	err     error
+}
+
+func NewRows(fields []string, records [][]*rdsdataservice.Field) *Rows {
+	return &Rows{
+		fields:  fields,
+		records: records,
+		index:   -1,
+	}
+}
+
+//executes the query and returns an iterator over its records, instead of parsing them all like GetResults.
+//the Data API returns all the records in a single response, which is held until they are read: page large results
+//with Limit and a keyset condition instead
+func (aq *AuroraQuery) GetRows(connexion AuroraConnexion, transactionId *string) (*Rows, error) {
+	context := ctxerror.SetContext(map[string]interface{}{
+		"connexion": connexion,
+		"query": aq.GetSql(),
+	})
+
+	res, err := aq.perform(connexion, transactionId)
+	if err != nil {
+		return nil, context.Wrap(err, "unable to perform query")
+	}
+
+	if res == nil {
+		return nil, context.New("query result is <nil>")
+	}
+
+	return NewRows(resultFields(aq.AuroraQueryBuilder.query.Select), res.Records), nil
+}
+
+//executes the query and calls callback for each row, stops at the first error returned by callback. see GetRows
+func (aq *AuroraQuery) ForEach(connexion AuroraConnexion, transactionId *string, callback func(rows *Rows) error) error {
+	rows, err := aq.GetRows(connexion, transactionId)
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		if err := callback(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (r *Rows) Fields() []string {
+	return r.fields
+}
+
+//moves to the next row, returns false at the end of the records or after an error
+func (r *Rows) Next() bool {
+	if r.err != nil || r.index+1 >= len(r.records) {
+		return false
+	}
+
+	if r.index >= 0 {
+		r.records[r.index] = nil
+	}
+
+	r.index++
+	if len(r.records[r.index]) != len(r.fields) {
+		r.err = ctxerror.SetContext(map[string]interface{}{
+			"fields": r.fields,
+			"row": r.index,
+		}).New("values and fields need to have the same length")
+		return false
+	}
+
+	return true
+}
+
+//first error met while iterating
+func (r *Rows) Err() error {
+	return r.err
+}
+
+//copies the values of the current row into dest, in the order of the fields.
+//dest are pointers, a pointer to a pointer is set to nil for NULL values
+func (r *Rows) Scan(dest ...interface{}) error {
+	record, err := r.current()
+	if err != nil {
+		return err
+	}
+
+	if len(dest) != len(record) {
+		return ctxerror.SetContext(map[string]interface{}{
+			"fields": r.fields,
+		}).New("Scan expects one destination per field")
+	}
+
+	for i, field := range record {
+		rDest := reflect.ValueOf(dest[i])
+		if rDest.Kind() != reflect.Ptr || rDest.IsNil() {
+			return ctxerror.SetContext(map[string]interface{}{
+				"field": r.fields[i],
+			}).New("Scan destination must be a non nil pointer")
+		}
+
+		if err := scanField(r.fields[i], field, rDest.Elem()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//copies the values of the current row into the fields of the struct pointed by dest, matched by `db` tag or field name.
+//fields without matching column are left untouched
+func (r *Rows) ScanStruct(dest interface{}) error {
+	record, err := r.current()
+	if err != nil {
+		return err
+	}
+
+	rDest := reflect.ValueOf(dest)
+	if rDest.Kind() != reflect.Ptr || rDest.IsNil() || rDest.Elem().Kind() != reflect.Struct {
+		return ctxerror.New("ScanStruct destination must be a non nil pointer to a struct")
+	}
+	rDest = rDest.Elem()
+
+	indexes := make(map[string]int)
+	rType := rDest.Type()
+	for i := 0; i < rType.NumField(); i++ {
+		field := rType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		indexes[columnName(field)] = i
+	}
+
+	for i, field := range record {
+		index, found := indexes[r.fields[i]]
+		if !found {
+			continue
+		}
+
+		if err := scanField(r.fields[i], field, rDest.Field(index)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//current row as a QueryResult, like the ones returned by GetResults
+func (r *Rows) Result() (QueryResult, error) {
+	record, err := r.current()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(QueryResult, len(record))
+	for i, field := range record {
+		value, err := rdsFieldToValue(field)
+		if err != nil {
+			return nil, ctxerror.SetContext(map[string]interface{}{
+				"field": r.fields[i],
+			}).Wrap(err, "unable to parse value")
+		}
+		result[r.fields[i]] = value
+	}
+
+	return result, nil
+}
+
+func (r *Rows) current() ([]*rdsdataservice.Field, error) {
+	if r.index < 0 || r.index >= len(r.records) {
+		return nil, ctxerror.New("no current row, Next must be called first")
+	}
+
+	return r.records[r.index], nil
+}
+
+//converts field and sets it to dest, int64 and float64 values can be set to the other numeric kinds
+func scanField(name string, field *rdsdataservice.Field, dest reflect.Value) error {
+	context := ctxerror.SetContext(map[string]interface{}{
+		"field": name,
+		"destination": dest.Type().String(),
+	})
+
+	value, err := rdsFieldToValue(field)
+	if err != nil {
+		return context.Wrap(err, "unable to parse value")
+	}
+
+	if value == nil {
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+
+	if dest.Kind() == reflect.Ptr {
+		pointer := reflect.New(dest.Type().Elem())
+		dest.Set(pointer)
+		dest = pointer.Elem()
+	}
+
+	rValue := reflect.ValueOf(value)
+	switch {
+	case rValue.Type().AssignableTo(dest.Type()):
+		dest.Set(rValue)
+	case isNumeric(rValue.Kind()) && isNumeric(dest.Kind()):
+		dest.Set(rValue.Convert(dest.Type()))
+	default:
+		return context.New("unable to set a " + rValue.Type().String() + " value")
+	}
+
+	return nil
+}
+
+func isNumeric(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Float64
+}
@@ -0,0 +1,158 @@
+package aurora
+
+import (
+	"github.com/mmatagrin/ctxerror"
+	"github.com/mmatagrin/sql-builder/structs"
+	"strconv"
+	"strings"
+)
+
+var safeMode = true
+
+//when enabled (default), UPDATE and DELETE queries without a restrictive WHERE are refused unless AllowFullTable is called
+func SetSafeMode(enabled bool) {
+	safeMode = enabled
+}
+
+//allows the UPDATE or DELETE to affect the whole table
+func (aqb *AuroraQueryBuilder) AllowFullTable() *AuroraQueryBuilder {
+	aqb.allowFullTable = true
+	return aqb
+}
+
+//the query fails and is rolled back when it affects more than max rows.
+//when executed in a transaction given by the caller, the caller is responsible for rolling it back
+func (aqb *AuroraQueryBuilder) MaxAffectedRows(max int64) *AuroraQueryBuilder {
+	aqb.maxAffectedRows = max
+	return aqb
+}
+
+func (mu *AuroraUpdateStruct) AllowFullTable() *AuroraUpdateStruct {
+	mu.builder.AllowFullTable()
+	return mu
+}
+
+func (mu *AuroraUpdateStruct) MaxAffectedRows(max int64) *AuroraUpdateStruct {
+	mu.builder.MaxAffectedRows(max)
+	return mu
+}
+
+func (ads *AuroraDeleteStruct) AllowFullTable() *AuroraDeleteStruct {
+	ads.builder.AllowFullTable()
+	return ads
+}
+
+func (ads *AuroraDeleteStruct) MaxAffectedRows(max int64) *AuroraDeleteStruct {
+	ads.builder.MaxAffectedRows(max)
+	return ads
+}
+
+func (aq *AuroraQuery) checkSafeMode() error {
+	if !safeMode || aq.AuroraQueryBuilder.allowFullTable {
+		return nil
+	}
+
+	if aq.QueryType != UPDATE && aq.QueryType != DELETE {
+		return nil
+	}
+
+	if !hasRestrictiveWhere(aq.AuroraQueryBuilder.query) {
+		return ctxerror.SetContext(map[string]interface{}{
+			"query": aq.GetSql(),
+		}).New("refusing to " + string(aq.QueryType) + " without a restrictive WHERE, call AllowFullTable to allow it")
+	}
+
+	return nil
+}
+
+//the where conditions are rendered in order, joined by AND or OR, and AND takes precedence: the where is restrictive
+//when every group of conditions joined by AND contains a condition that is not always true
+func hasRestrictiveWhere(query structs.Query) bool {
+	var groups []bool
+	add := func(or bool, restrictive bool) {
+		if or || len(groups) == 0 {
+			groups = append(groups, restrictive)
+			return
+		}
+		groups[len(groups)-1] = groups[len(groups)-1] || restrictive
+	}
+
+	for _, condition := range query.Where {
+		add(false, !isAlwaysTrue(condition))
+	}
+
+	for _, condition := range query.OrWhere {
+		add(true, !isAlwaysTrue(condition))
+	}
+
+	for _, queryParameter := range query.WhereQueryParameters {
+		add(false, isRestrictiveParameter(queryParameter))
+	}
+
+	for _, queryParameter := range query.OrWhereQueryParameters {
+		add(true, isRestrictiveParameter(queryParameter))
+	}
+
+	for _, restrictive := range groups {
+		if !restrictive {
+			return false
+		}
+	}
+
+	return len(groups) != 0
+}
+
+func isRestrictiveParameter(queryParameter structs.QueryParameter) bool {
+	query := structs.Query{
+		Where:                  queryParameter.WhereConditions,
+		OrWhere:                queryParameter.OrWhereConditions,
+		WhereQueryParameters:   queryParameter.WhereFieldsSeparated,
+		OrWhereQueryParameters: queryParameter.OrWhereFieldsSeparated,
+	}
+
+	return hasRestrictiveWhere(query)
+}
+
+func isAlwaysTrue(condition string) bool {
+	normalized := strings.ToLower(strings.NewReplacer(" ", "", "(", "", ")", "", "\t", "", "\n", "").Replace(condition))
+	switch normalized {
+	case "", "1", "true", "1=1", "'1'='1'":
+		return true
+	}
+
+	return false
+}
+
+//executes the query in a transaction rolled back when more than the max rows are affected
+func (aq *AuroraQuery) executeWithMaxAffectedRows(connexion AuroraConnexion, transactionId *string) (int64, error) {
+	maxAffectedRows := aq.AuroraQueryBuilder.maxAffectedRows
+	context := ctxerror.SetContext(map[string]interface{}{
+		"connexion": connexion,
+		"query": aq.GetSql(),
+		"max_affected_rows": maxAffectedRows,
+	})
+
+	var affected int64
+	err := inTransaction(connexion, transactionId, func(transactionId *string) error {
+		res, err := aq.perform(connexion, transactionId)
+		if err != nil {
+			return context.Wrap(err, "unable to execute query")
+		}
+
+		if res.NumberOfRecordsUpdated != nil {
+			affected = *res.NumberOfRecordsUpdated
+		}
+
+		if affected > maxAffectedRows {
+			context.AddContext("affected_rows", affected)
+			return context.New("query affected more than " + strconv.FormatInt(maxAffectedRows, 10) + " rows")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
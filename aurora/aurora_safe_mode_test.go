@@ -0,0 +1,44 @@
+package aurora
+
+import (
+	"testing"
+)
+
+func TestHasRestrictiveWhere(t *testing.T) {
+	alwaysTrue := func(p AuroraQueryParameter) AuroraQueryParameter {
+		return *p.Where("1 = 1")
+	}
+	byId := func(p AuroraQueryParameter) AuroraQueryParameter {
+		return *p.Where("id = :id").OrWhere("id = :other")
+	}
+
+	tests := []struct {
+		name     string
+		builder  *AuroraQueryBuilder
+		expected bool
+	}{
+		{name: "no condition", builder: CreateQueryBuilder().Delete("users"), expected: false},
+		{name: "condition", builder: CreateQueryBuilder().Delete("users").Where("id = :id"), expected: true},
+		{name: "always true", builder: CreateQueryBuilder().Delete("users").Where("( 1 = 1 )"), expected: false},
+		{name: "always true and condition", builder: CreateQueryBuilder().Delete("users").Where("1").Where("id = :id"), expected: true},
+		{name: "always true or condition", builder: CreateQueryBuilder().Delete("users").Where("1").OrWhere("id = :id"), expected: false},
+		{name: "condition or always true", builder: CreateQueryBuilder().Delete("users").Where("id = :id").OrWhere("TRUE"), expected: false},
+		{name: "condition or condition", builder: CreateQueryBuilder().Delete("users").Where("id = :id").OrWhere("id = :other"), expected: true},
+		{name: "or condition only", builder: CreateQueryBuilder().Delete("users").OrWhere("id = :id"), expected: true},
+		{name: "parenthesis", builder: CreateQueryBuilder().Delete("users").WhereParenthesis(byId), expected: true},
+		{name: "always true parenthesis", builder: CreateQueryBuilder().Delete("users").WhereParenthesis(alwaysTrue), expected: false},
+		{name: "condition or always true parenthesis", builder: CreateQueryBuilder().Delete("users").Where("id = :id").OrWhereParenthesis(alwaysTrue), expected: false},
+		{name: "always true or parenthesis", builder: CreateQueryBuilder().Delete("users").Where("1").OrWhereParenthesis(byId), expected: false},
+		//rendered: 1 OR id = :id AND (...), the parenthesis restricts the OR condition only
+		{name: "always true or condition and parenthesis", builder: CreateQueryBuilder().Delete("users").Where("1").OrWhere("id = :id").WhereParenthesis(byId), expected: false},
+		{name: "condition or condition and always true parenthesis", builder: CreateQueryBuilder().Delete("users").Where("id = :id").OrWhere("id = :other").WhereParenthesis(alwaysTrue), expected: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if restrictive := hasRestrictiveWhere(test.builder.query); restrictive != test.expected {
+				t.Errorf("got %t, expected %t for %s", restrictive, test.expected, test.builder.GetQuery().GetSql())
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package aurora
+
+import (
+	"strings"
+)
+
+//replaces the string and numeric literals of sql by ?, so that it can be sent to logs or traces without its values
+func SanitizeSql(sql string, dialect Dialect) string {
+	var sanitized strings.Builder
+	for i := 0; i < len(sql); i++ {
+		char := sql[i]
+
+		switch length := stringLiteralLength(sql, i, dialect); {
+		case length != 0:
+			sanitized.WriteByte('?')
+			i += length - 1
+		case char == '`' || char == '"':
+			end := quotedIdentifierEnd(sql, i)
+			sanitized.WriteString(sql[i : end+1])
+			i = end
+		case char >= '0' && char <= '9' && (i == 0 || !isNameChar(sql[i-1])):
+			for i+1 < len(sql) && (isNameChar(sql[i+1]) && sql[i+1] != ':') {
+				i++
+			}
+			sanitized.WriteByte('?')
+		default:
+			sanitized.WriteByte(char)
+		}
+	}
+
+	return sanitized.String()
+}
+
+//length of the string literal starting at sql[i], 0 when there is none. the literals are found like SplitScript does:
+//backslash escapes for mysql and E'...' strings, dollar quoting for postgresql. an unterminated literal runs to the end
+func stringLiteralLength(sql string, i int, dialect Dialect) int {
+	rest := sql[i:]
+	char := sql[i]
+	atWordStart := i == 0 || !isNameChar(sql[i-1])
+
+	end := 0
+	switch {
+	case char == '\'' || (char == '"' && dialect != POSTGRESQL):
+		end = quoteEnd(rest, char, dialect != POSTGRESQL)
+	case isEscapeString(rest, dialect) && atWordStart:
+		end = quoteEnd(rest[1:], '\'', true)
+		if end != -1 {
+			end++
+		}
+	case char == '$' && dialect == POSTGRESQL && atWordStart && dollarQuoteTag.MatchString(rest):
+		tag := dollarQuoteTag.FindString(rest)
+		end = strings.Index(rest[len(tag):], tag)
+		if end != -1 {
+			end += 2 * len(tag)
+		}
+	default:
+		return 0
+	}
+
+	if end == -1 {
+		return len(rest)
+	}
+
+	return end
+}
+
+//index of the quote closing the quoted identifier starting at sql[i], the end of sql when it is not closed
+func quotedIdentifierEnd(sql string, i int) int {
+	end := indexByteFrom(sql, sql[i], i+1)
+	if end == -1 {
+		return len(sql) - 1
+	}
+
+	return end
+}
@@ -0,0 +1,110 @@
+package aurora
+
+import (
+	"github.com/mmatagrin/sql-builder/structs"
+	"reflect"
+)
+
+//modifies a query before it is executed, ex: adds a tenant condition or a default order
+type Scope func(builder *AuroraQueryBuilder)
+
+//scopes by table
+var globalScopes = make(map[string][]Scope)
+
+//scope applied to every select, update and delete of table, including the union members and INSERT ... SELECT sources
+func RegisterScope(table string, scope Scope) {
+	globalScopes[table] = append(globalScopes[table], scope)
+}
+
+//scopes applied to this query only, after the global ones
+func (aqb *AuroraQueryBuilder) Scopes(scopes ...Scope) *AuroraQueryBuilder {
+	aqb.scopes = append(aqb.scopes, scopes...)
+	return aqb
+}
+
+//the scopes registered with RegisterScope are not applied to this query. the union members and INSERT ... SELECT sources
+//keep their own scopes, call it on them too
+func (aqb *AuroraQueryBuilder) WithoutGlobalScopes() *AuroraQueryBuilder {
+	aqb.withoutGlobalScopes = true
+	return aqb
+}
+
+//copy of the builder with its scopes applied, the builder itself is left untouched so GetQuery can be called again
+func (aqb AuroraQueryBuilder) withScopes() AuroraQueryBuilder {
+	var scopes []Scope
+	if !aqb.withoutGlobalScopes {
+		scopes = append(scopes, globalScopes[tableName(aqb.mainTable())]...)
+	}
+	scopes = append(scopes, aqb.scopes...)
+
+	builder := aqb
+	if len(scopes) != 0 {
+		builder = aqb.clone()
+		builder.scopes = nil
+		for _, scope := range scopes {
+			scope(&builder)
+		}
+	}
+
+	return builder
+}
+
+//query of a union member or INSERT ... SELECT source with its own scopes applied, the builders only store the queries.
+//the tenant bound to the member is bound to the whole query, which has a single tenant
+func (aqb *AuroraQueryBuilder) memberQuery(member AuroraQueryBuilder, function string) structs.Query {
+	member = member.withScopes()
+
+	if member.tenant.bound || member.tenant.disabled {
+		if (aqb.tenant.bound || aqb.tenant.disabled) && !reflect.DeepEqual(aqb.tenant, member.tenant) {
+			panic("Err, function " + function + " expected a builder bound to the same tenant")
+		}
+		aqb.tenant = member.tenant
+	}
+
+	return member.query
+}
+
+//table selected, updated or deleted by the query
+func (aqb *AuroraQueryBuilder) mainTable() string {
+	switch {
+	case aqb.query.Delete != "":
+		return aqb.query.Delete
+	case aqb.query.Update.Table != "":
+		return aqb.query.Update.Table
+	case aqb.query.Insert.Into != "":
+		return ""
+	default:
+		return aqb.query.From
+	}
+}
+
+//copy of the builder whose slices are reallocated on append, so that modifying the copy never alters the builder
+func (aqb AuroraQueryBuilder) clone() AuroraQueryBuilder {
+	query := &aqb.query
+	query.Select = query.Select[:len(query.Select):len(query.Select)]
+	query.Join = query.Join[:len(query.Join):len(query.Join)]
+	query.Where = query.Where[:len(query.Where):len(query.Where)]
+	query.WhereQueryParameters = query.WhereQueryParameters[:len(query.WhereQueryParameters):len(query.WhereQueryParameters)]
+	query.OrWhereQueryParameters = query.OrWhereQueryParameters[:len(query.OrWhereQueryParameters):len(query.OrWhereQueryParameters)]
+	query.OrWhere = query.OrWhere[:len(query.OrWhere):len(query.OrWhere)]
+	query.Order = query.Order[:len(query.Order):len(query.Order)]
+	query.Having = query.Having[:len(query.Having):len(query.Having)]
+	query.HavingQueryParameters = query.HavingQueryParameters[:len(query.HavingQueryParameters):len(query.HavingQueryParameters)]
+	query.HavingOrCondition = query.HavingOrCondition[:len(query.HavingOrCondition):len(query.HavingOrCondition)]
+	query.HavingOrQueryParameters = query.HavingOrQueryParameters[:len(query.HavingOrQueryParameters):len(query.HavingOrQueryParameters)]
+	query.GroupBy = query.GroupBy[:len(query.GroupBy):len(query.GroupBy)]
+	query.Union = query.Union[:len(query.Union):len(query.Union)]
+	query.Insert.Values = query.Insert.Values[:len(query.Insert.Values):len(query.Insert.Values)]
+	query.Insert.Updates = query.Insert.Updates[:len(query.Insert.Updates):len(query.Insert.Updates)]
+	query.Update.Set = query.Update.Set[:len(query.Update.Set):len(query.Update.Set)]
+
+	if query.Parameters != nil {
+		parameters := make(map[string]interface{}, len(query.Parameters))
+		for key, value := range query.Parameters {
+			parameters[key] = value
+		}
+		query.Parameters = parameters
+	}
+
+	return aqb
+}
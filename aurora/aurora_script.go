@@ -0,0 +1,219 @@
+package aurora
+
+import (
+	"github.com/mmatagrin/ctxerror"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//statement of a sql script, Line is the line of the script where it starts
+type Statement struct {
+	Sql  string
+	Line int
+}
+
+var dollarQuoteTag = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+//runs the statements of the script in a single transaction, rolled back when a statement fails.
+//separator is the initial delimiter (";" when empty) and can be changed by DELIMITER directives
+func ExecuteScript(reader io.Reader, separator string, connexion AuroraConnexion) ctxerror.CtxErrorTraceI {
+	context := ctxerror.SetContext(map[string]interface{}{})
+
+	statements, err := SplitScript(reader, separator, defaultDialect)
+	if err != nil {
+		return context.Wrap(err, "unable to parse script")
+	}
+
+	err = inTransaction(connexion, nil, func(transactionId *string) error {
+		return executeStatements(statements, connexion, *transactionId)
+	})
+	if err != nil {
+		return context.Wrap(err, "unable to execute script")
+	}
+
+	return nil
+}
+
+func executeStatements(statements []Statement, connexion AuroraConnexion, transactionId string) error {
+	for _, statement := range statements {
+		_, err := PerformAuroraQuery(statement.Sql, nil, connexion, &transactionId)
+		if err != nil {
+			return ctxerror.SetContext(map[string]interface{}{
+				"current_query": statement.Sql,
+				"line":          statement.Line,
+			}).Wrap(err, "unable to perform query at line "+strconv.Itoa(statement.Line))
+		}
+	}
+
+	return nil
+}
+
+//splits a sql script into statements. quotes, comments, DELIMITER directives (mysql) and dollar quoting (postgres) are
+//taken into account, and the statements containing only spaces or comments are skipped
+func SplitScript(reader io.Reader, delimiter string, dialect Dialect) ([]Statement, error) {
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, ctxerror.Wrap(err, "unable to read script")
+	}
+
+	if delimiter == "" {
+		delimiter = ";"
+	}
+
+	script := string(content)
+	statements := []Statement{}
+	var current strings.Builder
+	line := 1
+	//line of the first token of the current statement, 0 while it has none
+	startLine := 0
+	atLineStart := true
+
+	flush := func() {
+		if startLine != 0 {
+			statements = append(statements, Statement{
+				Sql:  strings.TrimSpace(current.String()),
+				Line: startLine,
+			})
+		}
+		current.Reset()
+		startLine = 0
+	}
+
+	for i := 0; i < len(script); {
+		char := script[i]
+		rest := script[i:]
+
+		if atLineStart && startLine == 0 && isDelimiterDirective(rest) {
+			end := strings.IndexByte(rest, '\n')
+			if end == -1 {
+				end = len(rest)
+			}
+
+			delimiter = strings.TrimSpace(rest[len("DELIMITER"):end])
+			if delimiter == "" {
+				return nil, lineError(line, "DELIMITER without delimiter")
+			}
+			current.Reset()
+			i += end
+			continue
+		}
+
+		var end int
+		switch {
+		case strings.HasPrefix(rest, delimiter):
+			flush()
+			i += len(delimiter)
+			atLineStart = false
+			continue
+		case char == '\n':
+			line++
+			atLineStart = true
+			current.WriteByte(char)
+			i++
+			continue
+		case char == ' ' || char == '\t' || char == '\r':
+			current.WriteByte(char)
+			i++
+			continue
+		case isLineComment(rest, dialect):
+			end = strings.IndexByte(rest, '\n')
+			if end == -1 {
+				end = len(rest)
+			}
+			current.WriteString(rest[:end])
+			i += end
+			continue
+		case strings.HasPrefix(rest, "/*"):
+			end = strings.Index(rest[2:], "*/")
+			if end == -1 {
+				return nil, lineError(line, "unterminated comment")
+			}
+			end += 4
+		case char == '\'' || char == '"' || char == '`':
+			end = quoteEnd(rest, char, dialect != POSTGRESQL && char != '`')
+			if end == -1 {
+				return nil, lineError(line, "unterminated quote")
+			}
+		case isEscapeString(rest, dialect) && (i == 0 || !isNameChar(script[i-1])):
+			end = quoteEnd(rest[1:], '\'', true)
+			if end == -1 {
+				return nil, lineError(line, "unterminated quote")
+			}
+			end++
+		case char == '$' && dialect == POSTGRESQL && dollarQuoteTag.MatchString(rest):
+			tag := dollarQuoteTag.FindString(rest)
+			end = strings.Index(rest[len(tag):], tag)
+			if end == -1 {
+				return nil, lineError(line, "unterminated dollar quote "+tag)
+			}
+			end += 2 * len(tag)
+		default:
+			end = 1
+		}
+
+		//mysql executable comments /*! ... */ are statements
+		if startLine == 0 && (!strings.HasPrefix(rest, "/*") || strings.HasPrefix(rest, "/*!")) {
+			//drops the spaces and comments preceding the statement
+			current.Reset()
+			startLine = line
+		}
+		current.WriteString(rest[:end])
+		line += strings.Count(rest[:end], "\n")
+		atLineStart = false
+		i += end
+	}
+
+	flush()
+
+	return statements, nil
+}
+
+func isDelimiterDirective(rest string) bool {
+	keyword := len("DELIMITER")
+	return len(rest) > keyword && strings.EqualFold(rest[:keyword], "DELIMITER") &&
+		(rest[keyword] == ' ' || rest[keyword] == '\t')
+}
+
+//mysql needs a space after --, and also accepts #
+func isLineComment(rest string, dialect Dialect) bool {
+	if dialect != POSTGRESQL && strings.HasPrefix(rest, "#") {
+		return true
+	}
+
+	if !strings.HasPrefix(rest, "--") {
+		return false
+	}
+
+	return dialect == POSTGRESQL || len(rest) == 2 || rest[2] == ' ' || rest[2] == '\t' || rest[2] == '\n' || rest[2] == '\r'
+}
+
+//postgresql E'...' strings, whose backslashes escape the next character
+func isEscapeString(rest string, dialect Dialect) bool {
+	return dialect == POSTGRESQL && len(rest) > 1 && (rest[0] == 'E' || rest[0] == 'e') && rest[1] == '\''
+}
+
+//length of the quoted string or identifier at the start of rest, -1 when it is not closed.
+//doubled quotes are part of it, and backslash escapes when enabled: mysql strings and postgresql E'...' strings
+func quoteEnd(rest string, quote byte, backslashEscapes bool) int {
+	for i := 1; i < len(rest); i++ {
+		switch {
+		case rest[i] == '\\' && backslashEscapes:
+			i++
+		case rest[i] == quote && i+1 < len(rest) && rest[i+1] == quote:
+			i++
+		case rest[i] == quote:
+			return i + 1
+		}
+	}
+
+	return -1
+}
+
+func lineError(line int, message string) error {
+	return ctxerror.SetContext(map[string]interface{}{
+		"line": line,
+	}).New(message + " at line " + strconv.Itoa(line))
+}
@@ -0,0 +1,28 @@
+//go:build go1.16
+// +build go1.16
+
+package aurora
+
+import (
+	"github.com/mmatagrin/ctxerror"
+	"io/fs"
+)
+
+//runs the statements of a file of fsys (an embed.FS...) in a single transaction, see ExecuteScript
+func ExecuteFS(fsys fs.FS, filePath, separator string, connexion AuroraConnexion) ctxerror.CtxErrorTraceI {
+	context := ctxerror.SetContext(map[string]interface{}{
+		"file": filePath,
+	})
+
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		return context.Wrap(err, "unable to open source file")
+	}
+	defer file.Close()
+
+	if err := ExecuteScript(file, separator, connexion); err != nil {
+		return context.Wrap(err, "unable to execute source file")
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package aurora
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitScript(t *testing.T) {
+	tests := []struct {
+		name      string
+		script    string
+		delimiter string
+		dialect   Dialect
+		expected  []Statement
+	}{
+		{
+			name:    "statements and line numbers",
+			script:  "SELECT 1;\n\nSELECT\n  2;\nSELECT 3",
+			dialect: MYSQL,
+			expected: []Statement{
+				{Sql: "SELECT 1", Line: 1},
+				{Sql: "SELECT\n  2", Line: 3},
+				{Sql: "SELECT 3", Line: 5},
+			},
+		},
+		{
+			name:     "empty statements are skipped",
+			script:   ";\n  ;\nSELECT 1;;",
+			dialect:  MYSQL,
+			expected: []Statement{{Sql: "SELECT 1", Line: 3}},
+		},
+		{
+			name:      "custom delimiter",
+			script:    "SELECT 1 //\nSELECT 2 //",
+			delimiter: "//",
+			dialect:   MYSQL,
+			expected: []Statement{
+				{Sql: "SELECT 1", Line: 1},
+				{Sql: "SELECT 2", Line: 2},
+			},
+		},
+		{
+			name:    "delimiter in quotes",
+			script:  "SELECT 'a;b', \"c;d\", `e;f`;\nSELECT 'it''s;';",
+			dialect: MYSQL,
+			expected: []Statement{
+				{Sql: "SELECT 'a;b', \"c;d\", `e;f`", Line: 1},
+				{Sql: "SELECT 'it''s;'", Line: 2},
+			},
+		},
+		{
+			name:     "mysql backslash escape",
+			script:   `SELECT 'it\'s;';`,
+			dialect:  MYSQL,
+			expected: []Statement{{Sql: `SELECT 'it\'s;'`, Line: 1}},
+		},
+		{
+			name:    "postgresql backslash is not an escape",
+			script:  `SELECT 'a\'; SELECT 2;`,
+			dialect: POSTGRESQL,
+			expected: []Statement{
+				{Sql: `SELECT 'a\'`, Line: 1},
+				{Sql: "SELECT 2", Line: 1},
+			},
+		},
+		{
+			name:    "postgresql escape string",
+			script:  "SELECT E'it\\'s;';\nSELECT e'\\\\';",
+			dialect: POSTGRESQL,
+			expected: []Statement{
+				{Sql: `SELECT E'it\'s;'`, Line: 1},
+				{Sql: `SELECT e'\\'`, Line: 2},
+			},
+		},
+		{
+			name:     "multi line string",
+			script:   "INSERT INTO t VALUES ('a\nb;\nc');\nSELECT 1;",
+			dialect:  MYSQL,
+			expected: []Statement{{Sql: "INSERT INTO t VALUES ('a\nb;\nc')", Line: 1}, {Sql: "SELECT 1", Line: 4}},
+		},
+		{
+			name:    "comments",
+			script:  "-- first; comment\n# second; comment\n/* block;\ncomment */ SELECT 1; -- trailing\nSELECT 2 /* ; */;",
+			dialect: MYSQL,
+			expected: []Statement{
+				{Sql: "SELECT 1", Line: 4},
+				{Sql: "SELECT 2 /* ; */", Line: 5},
+			},
+		},
+		{
+			name:     "mysql needs a space after --",
+			script:   "SELECT 1--1;",
+			dialect:  MYSQL,
+			expected: []Statement{{Sql: "SELECT 1--1", Line: 1}},
+		},
+		{
+			name:     "# is not a postgresql comment",
+			script:   "SELECT 1 # 2;",
+			dialect:  POSTGRESQL,
+			expected: []Statement{{Sql: "SELECT 1 # 2", Line: 1}},
+		},
+		{
+			name:     "mysql executable comment",
+			script:   "/*!40101 SET NAMES utf8 */;",
+			dialect:  MYSQL,
+			expected: []Statement{{Sql: "/*!40101 SET NAMES utf8 */", Line: 1}},
+		},
+		{
+			name: "delimiter directive",
+			script: "DELIMITER $$\n" +
+				"CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\nEND$$\n" +
+				"delimiter ;\n" +
+				"SELECT 2;",
+			dialect: MYSQL,
+			expected: []Statement{
+				{Sql: "CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\nEND", Line: 2},
+				{Sql: "SELECT 2", Line: 7},
+			},
+		},
+		{
+			name: "dollar quoting",
+			script: "CREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql;\n" +
+				"SELECT $tag$a;$$;b$tag$;",
+			dialect: POSTGRESQL,
+			expected: []Statement{
+				{Sql: "CREATE FUNCTION f() RETURNS int AS $$\nBEGIN\n  RETURN 1;\nEND;\n$$ LANGUAGE plpgsql", Line: 1},
+				{Sql: "SELECT $tag$a;$$;b$tag$", Line: 6},
+			},
+		},
+		{
+			name:     "positional parameters are not dollar quotes",
+			script:   "SELECT $1, $2;",
+			dialect:  POSTGRESQL,
+			expected: []Statement{{Sql: "SELECT $1, $2", Line: 1}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statements, err := SplitScript(strings.NewReader(test.script), test.delimiter, test.dialect)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(statements, test.expected) {
+				t.Errorf("got %#v, expected %#v", statements, test.expected)
+			}
+		})
+	}
+}
+
+func TestSplitScriptErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		dialect  Dialect
+		expected string
+	}{
+		{name: "unterminated quote", script: "SELECT 1;\nSELECT 'a;", dialect: MYSQL, expected: "unterminated quote at line 2"},
+		{name: "unterminated escape string", script: `SELECT E'a\';`, dialect: POSTGRESQL, expected: "unterminated quote at line 1"},
+		{name: "unterminated comment", script: "SELECT 1;\n\n/* comment", dialect: MYSQL, expected: "unterminated comment at line 3"},
+		{name: "unterminated dollar quote", script: "SELECT $tag$a$$;", dialect: POSTGRESQL, expected: "unterminated dollar quote $tag$ at line 1"},
+		{name: "delimiter without delimiter", script: "DELIMITER \nSELECT 1;", dialect: MYSQL, expected: "DELIMITER without delimiter at line 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := SplitScript(strings.NewReader(test.script), ";", test.dialect)
+			if err == nil {
+				t.Fatalf("expected error %q", test.expected)
+			}
+
+			if !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("got error %q, expected %q", err.Error(), test.expected)
+			}
+		})
+	}
+}
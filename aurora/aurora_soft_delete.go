@@ -0,0 +1,169 @@
+package aurora
+
+import (
+	"github.com/mmatagrin/sql-builder/structs"
+	"strings"
+)
+
+const (
+	WITHOUT_TRASHED = iota
+	WITH_TRASHED
+	ONLY_TRASHED
+)
+
+//soft delete column by table
+var softDeleteColumns = make(map[string]string)
+
+//the rows of table whose column is not NULL are considered deleted, ex: RegisterSoftDelete("users", "deleted_at").
+//selects from the table exclude them unless WithTrashed or OnlyTrashed is called, selects joining the table always exclude them
+func RegisterSoftDelete(table string, column string) {
+	softDeleteColumns[table] = column
+}
+
+//includes the soft deleted rows
+func (aqb *AuroraQueryBuilder) WithTrashed() *AuroraQueryBuilder {
+	aqb.query.Trashed = WITH_TRASHED
+	return aqb
+}
+
+//only selects the soft deleted rows
+func (aqb *AuroraQueryBuilder) OnlyTrashed() *AuroraQueryBuilder {
+	aqb.query.Trashed = ONLY_TRASHED
+	return aqb
+}
+
+//UPDATE table SET column = NOW() for the rows matching the where conditions that are not already deleted
+func (aqb *AuroraQueryBuilder) SoftDelete(table string) *AuroraQueryBuilder {
+	column, ok := softDeleteColumns[tableName(table)]
+	if !ok {
+		panic("Err, function SoftDelete expected a table registered with RegisterSoftDelete")
+	}
+
+	aqb.Update(table)
+	aqb.SetExpr(column, "NOW()")
+	aqb.query.Update.SoftDelete = true
+	return aqb
+}
+
+//adds the soft delete condition of the selected table to the where conditions, and the one of the joined tables to
+//their join conditions. WithTrashed and OnlyTrashed only apply to the selected table
+func withSoftDeleteFilter(query structs.Query, dialect Dialect) structs.Query {
+	query = withJoinConditions(query, func(join structs.Join) string {
+		column, ok := registeredTable(softDeleteColumns, join.SrcTable, dialect)
+		if !ok {
+			return ""
+		}
+
+		return quoteIdentifier(tableAlias(join.SrcTable)+"."+column, dialect) + " IS NULL"
+	})
+
+	column, ok := registeredTable(softDeleteColumns, query.From, dialect)
+	if !ok || query.Trashed == WITH_TRASHED {
+		return query
+	}
+
+	condition := quoteIdentifier(tableAlias(query.From)+"."+column, dialect) + " IS NULL"
+	if query.Trashed == ONLY_TRASHED {
+		condition = quoteIdentifier(tableAlias(query.From)+"."+column, dialect) + " IS NOT NULL"
+	}
+
+	return withConditions(query, condition)
+}
+
+//the rows already soft deleted are not deleted again
+func withSoftDeletedExcluded(query structs.Query, dialect Dialect) structs.Query {
+	if !query.Update.SoftDelete {
+		return query
+	}
+
+	column := softDeleteColumns[tableName(query.Update.Table)]
+	return withConditions(query, quoteIdentifier(tableAlias(query.Update.Table)+"."+column, dialect)+" IS NULL")
+}
+
+//copy of query with conditions ANDed to its where conditions, which are grouped in parentheses so that an OrWhere
+//cannot bypass them. the builder conditions are never modified
+func withConditions(query structs.Query, conditions ...string) structs.Query {
+	where := make([]string, 0, len(conditions)+1)
+	if group := strings.TrimPrefix(strings.TrimSpace(generateWhere(query)), "WHERE"); group != "" {
+		where = append(where, "("+strings.TrimSpace(group)+")")
+	}
+
+	query.Where = append(where, conditions...)
+	query.OrWhere = nil
+	query.WhereQueryParameters = nil
+	query.OrWhereQueryParameters = nil
+
+	return query
+}
+
+//copy of query with the condition returned for each join ANDed to its conditions, no condition is added for "".
+//the builder joins are never modified
+func withJoinConditions(query structs.Query, condition func(join structs.Join) string) structs.Query {
+	var joins []structs.Join
+	for i, join := range query.Join {
+		joinCondition := condition(join)
+		if joinCondition == "" {
+			continue
+		}
+
+		if joins == nil {
+			joins = make([]structs.Join, len(query.Join))
+			copy(joins, query.Join)
+		}
+
+		conditions := make([]string, len(join.Conditions), len(join.Conditions)+1)
+		copy(conditions, join.Conditions)
+		joins[i].Conditions = append(conditions, joinCondition)
+	}
+
+	if joins != nil {
+		query.Join = joins
+	}
+
+	return query
+}
+
+//name of the table of a table expression such as "users", "users u" or "users AS u"
+func tableName(table string) string {
+	fields := strings.Fields(table)
+	if len(fields) == 0 {
+		return table
+	}
+
+	return strings.Trim(fields[0], "`\"")
+}
+
+//value registered for the table of a table expression. a schema qualified name ("app.orders") matches the table
+//registered without schema and the other way around, the names are compared like the database does: without case for
+//mysql and for the unquoted postgresql names
+func registeredTable(registry map[string]string, table string, dialect Dialect) (string, bool) {
+	if value, ok := registry[tableName(table)]; ok {
+		return value, true
+	}
+
+	key := tableKey(table, dialect)
+	for registered, value := range registry {
+		if tableKey(registered, dialect) == key {
+			return value, true
+		}
+	}
+
+	return "", false
+}
+
+func tableKey(table string, dialect Dialect) string {
+	name := table
+	if fields := strings.Fields(table); len(fields) != 0 {
+		name = fields[0]
+	}
+
+	if i := strings.LastIndexByte(name, '.'); i != -1 {
+		name = name[i+1:]
+	}
+
+	if dialect == POSTGRESQL && strings.HasPrefix(name, `"`) {
+		return strings.Trim(name, `"`)
+	}
+
+	return strings.ToLower(strings.Trim(name, "`\""))
+}
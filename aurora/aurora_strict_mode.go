@@ -0,0 +1,172 @@
+package aurora
+
+import (
+	"github.com/mmatagrin/ctxerror"
+	"github.com/mmatagrin/sql-builder/structs"
+	"log"
+)
+
+var strictMode = false
+
+var expressionAuditLogger *log.Logger
+
+//when enabled, queries whose raw expressions (Select, Where, Having, SetExpr...) contain string or numeric literals
+//are refused: values must be bound as parameters
+func SetStrictMode(enabled bool) {
+	strictMode = enabled
+}
+
+//logs the raw expressions containing literals of every executed query without refusing them, nil disables the audit
+func AuditExpressions(logger *log.Logger) {
+	expressionAuditLogger = logger
+}
+
+//enables the strict mode for this query only
+func (aqb *AuroraQueryBuilder) Strict() *AuroraQueryBuilder {
+	aqb.strict = true
+	return aqb
+}
+
+type offendingExpression struct {
+	expression string
+	reason     string
+}
+
+func (aq *AuroraQuery) checkStrictMode() error {
+	strict := strictMode || aq.AuroraQueryBuilder.strict
+	if !strict && expressionAuditLogger == nil {
+		return nil
+	}
+
+	offenders := lintQuery(aq.AuroraQueryBuilder.query, aq.AuroraQueryBuilder.getDialect())
+	if len(offenders) == 0 {
+		return nil
+	}
+
+	if expressionAuditLogger != nil {
+		for _, offender := range offenders {
+			expressionAuditLogger.Printf("sql-builder: raw expression contains a %s: %s (query: %s)", offender.reason, offender.expression, aq.GetSql())
+		}
+	}
+
+	if strict {
+		return ctxerror.SetContext(map[string]interface{}{
+			"expression": offenders[0].expression,
+			"query": aq.GetSql(),
+		}).New("raw expression contains a " + offenders[0].reason + ", bind it as a parameter")
+	}
+
+	return nil
+}
+
+func lintQuery(query structs.Query, dialect Dialect) []offendingExpression {
+	var expressions []string
+	expressions = append(expressions, query.Select...)
+	expressions = append(expressions, query.Where...)
+	expressions = append(expressions, query.OrWhere...)
+	expressions = append(expressions, query.Having...)
+	expressions = append(expressions, query.HavingOrCondition...)
+
+	for _, queryParameters := range [][]structs.QueryParameter{query.WhereQueryParameters, query.OrWhereQueryParameters, query.HavingQueryParameters, query.HavingOrQueryParameters} {
+		for _, queryParameter := range queryParameters {
+			expressions = append(expressions, queryParameterExpressions(queryParameter)...)
+		}
+	}
+
+	for _, join := range query.Join {
+		expressions = append(expressions, join.Conditions...)
+	}
+
+	for _, assignment := range query.Update.Set {
+		expressions = append(expressions, assignment.Expression)
+	}
+
+	for _, assignment := range query.Insert.Updates {
+		expressions = append(expressions, assignment.Expression)
+	}
+
+	for _, row := range query.Insert.Values {
+		for _, value := range row {
+			if expression, ok := value.(Expression); ok {
+				expressions = append(expressions, string(expression))
+			}
+		}
+	}
+
+	var offenders []offendingExpression
+	for _, expression := range expressions {
+		if reason := findLiteral(expression, dialect); reason != "" {
+			offenders = append(offenders, offendingExpression{expression: expression, reason: reason})
+		}
+	}
+
+	for _, union := range query.Union {
+		offenders = append(offenders, lintQuery(union, dialect)...)
+	}
+
+	if query.Insert.Select != nil {
+		offenders = append(offenders, lintQuery(*query.Insert.Select, dialect)...)
+	}
+
+	return offenders
+}
+
+func queryParameterExpressions(queryParameter structs.QueryParameter) []string {
+	var expressions []string
+	expressions = append(expressions, queryParameter.WhereConditions...)
+	expressions = append(expressions, queryParameter.OrWhereConditions...)
+
+	for _, separated := range queryParameter.WhereFieldsSeparated {
+		expressions = append(expressions, queryParameterExpressions(separated)...)
+	}
+
+	for _, separated := range queryParameter.OrWhereFieldsSeparated {
+		expressions = append(expressions, queryParameterExpressions(separated)...)
+	}
+
+	return expressions
+}
+
+//returns "string literal", "numeric literal" or "quote that is never closed" when the expression contains one, "" otherwise.
+//quoted identifiers, parameters (:name, $1) and digits inside names (t1.col2) are not literals, postgresql dollar
+//quoted strings ($$text$$, $tag$text$tag$) are
+func findLiteral(expression string, dialect Dialect) string {
+	for i := 0; i < len(expression); i++ {
+		char := expression[i]
+
+		switch {
+		case char == '\'' || (char == '"' && dialect != POSTGRESQL):
+			return "string literal"
+		case char == '$' && dialect == POSTGRESQL && (i == 0 || !isNameChar(expression[i-1])) && dollarQuoteTag.MatchString(expression[i:]):
+			return "string literal"
+		case char == '`' || char == '"':
+			//quoted identifier, the rest of an unterminated one can not be told apart from literals
+			end := indexByteFrom(expression, char, i+1)
+			if end == -1 {
+				return "quote that is never closed"
+			}
+			i = end
+		case char >= '0' && char <= '9':
+			if i == 0 || !isNameChar(expression[i-1]) {
+				return "numeric literal"
+			}
+		}
+	}
+
+	return ""
+}
+
+func isNameChar(char byte) bool {
+	return char == '_' || char == '$' || char == ':' || char == '.' ||
+		(char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+}
+
+func indexByteFrom(s string, char byte, from int) int {
+	for i := from; i < len(s); i++ {
+		if s[i] == char {
+			return i
+		}
+	}
+
+	return -1
+}
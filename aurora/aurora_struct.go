@@ -0,0 +1,92 @@
+package aurora
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//returns the columns and values of a map[string]interface{} or of a struct (or pointer to struct).
+//struct fields are named after their `db` tag, or the field name when there is no tag,
+//fields tagged `db:"-"` and unexported fields are skipped
+func rowToColumns(row interface{}) ([]string, []interface{}, bool) {
+	if mapRow, ok := row.(map[string]interface{}); ok {
+		columns := make([]string, 0, len(mapRow))
+		for column := range mapRow {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			values[i] = mapRow[column]
+		}
+
+		return columns, values, true
+	}
+
+	rValue := reflect.ValueOf(row)
+	if rValue.Kind() == reflect.Ptr {
+		if rValue.IsNil() {
+			return nil, nil, false
+		}
+		rValue = rValue.Elem()
+	}
+
+	if rValue.Kind() != reflect.Struct {
+		return nil, nil, false
+	}
+
+	var columns []string
+	var values []interface{}
+	rType := rValue.Type()
+	for i := 0; i < rType.NumField(); i++ {
+		field := rType.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		column := columnName(field)
+		if column == "-" {
+			continue
+		}
+
+		columns = append(columns, column)
+		values = append(values, rValue.Field(i).Interface())
+	}
+
+	return columns, values, true
+}
+
+func columnName(field reflect.StructField) string {
+	tag := field.Tag.Get("db")
+	if tag == "" {
+		return field.Name
+	}
+
+	return strings.Split(tag, ",")[0]
+}
+
+//returns the values of row ordered as columns, the second value is false when a column is missing from row
+func rowValues(row interface{}, columns []string) ([]interface{}, bool) {
+	rowColumns, fieldValues, ok := rowToColumns(row)
+	if !ok {
+		return nil, false
+	}
+
+	indexes := make(map[string]int, len(rowColumns))
+	for i, column := range rowColumns {
+		indexes[column] = i
+	}
+
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		index, found := indexes[column]
+		if !found {
+			return nil, false
+		}
+		values[i] = fieldValues[index]
+	}
+
+	return values, true
+}
@@ -0,0 +1,185 @@
+package aurora
+
+import (
+	"github.com/aws/aws-sdk-go/service/rdsdataservice"
+	"github.com/mmatagrin/ctxerror"
+	"github.com/mmatagrin/sql-builder/structs"
+)
+
+//name of the parameter holding the bound tenant, reserved: a query fails when it is given by the caller
+const TENANT_PARAMETER = "_tenant_id"
+
+//tenant column by table
+var tenantColumns = make(map[string]string)
+
+type tenantBinding struct {
+	id       interface{}
+	bound    bool
+	disabled bool
+}
+
+//rows of table belong to the tenant stored in column, ex: RegisterTenantTable("orders", "tenant_id").
+//every query on the table (selected, joined, updated, deleted, inserted or in a union) is then restricted to the tenant
+//bound with ForTenant, and fails when no tenant is bound. the table matches its schema qualified names ("app.orders").
+//AuroraInsert, AuroraInsertIgnore, AuroraReplace, AuroraUpdate and AuroraDelete bind no tenant, use their Tenant(id) versions
+func RegisterTenantTable(table string, column string) {
+	tenantColumns[table] = column
+}
+
+func (aqb *AuroraQueryBuilder) ForTenant(tenantId interface{}) *AuroraQueryBuilder {
+	aqb.tenant = tenantBinding{id: tenantId, bound: true}
+	return aqb
+}
+
+//the query is not restricted to a tenant, for administration tasks only
+func (aqb *AuroraQueryBuilder) WithoutTenant() *AuroraQueryBuilder {
+	aqb.tenant = tenantBinding{disabled: true}
+	return aqb
+}
+
+func (mu *AuroraUpdateStruct) ForTenant(tenantId interface{}) *AuroraUpdateStruct {
+	mu.builder.ForTenant(tenantId)
+	return mu
+}
+
+func (ads *AuroraDeleteStruct) ForTenant(tenantId interface{}) *AuroraDeleteStruct {
+	ads.builder.ForTenant(tenantId)
+	return ads
+}
+
+//creates the builders of a tenant, ex: Tenant(42).CreateQueryBuilder().Select("id").From("orders")
+type TenantScope struct {
+	tenantId interface{}
+}
+
+func Tenant(tenantId interface{}) TenantScope {
+	return TenantScope{tenantId: tenantId}
+}
+
+func (ts TenantScope) CreateQueryBuilder() *AuroraQueryBuilder {
+	return CreateQueryBuilder().ForTenant(ts.tenantId)
+}
+
+func (ts TenantScope) AuroraInsert(table string, columns []string, values [][]interface{}, connexion AuroraConnexion, transactionId *string) (*rdsdataservice.ExecuteStatementOutput, error) {
+	return insert(ts.CreateQueryBuilder(), table, columns, values, connexion, INSERT_NOT_IGNORE, transactionId)
+}
+
+func (ts TenantScope) AuroraInsertIgnore(table string, columns []string, values [][]interface{}, connexion AuroraConnexion, transactionId *string) (*rdsdataservice.ExecuteStatementOutput, error) {
+	return insert(ts.CreateQueryBuilder(), table, columns, values, connexion, INSERT_IGNORE, transactionId)
+}
+
+func (ts TenantScope) AuroraReplace(table string, columns []string, values [][]interface{}, connexion AuroraConnexion, transactionId *string) (*rdsdataservice.ExecuteStatementOutput, error) {
+	return insert(ts.CreateQueryBuilder(), table, columns, values, connexion, REPLACE, transactionId)
+}
+
+func (ts TenantScope) AuroraUpdate(table string, expressions []string) *AuroraUpdateStruct {
+	return AuroraUpdate(table, expressions).ForTenant(ts.tenantId)
+}
+
+func (ts TenantScope) AuroraDelete(tableName string) *AuroraDeleteStruct {
+	return AuroraDelete(tableName).ForTenant(ts.tenantId)
+}
+
+//copy of query restricted to the bound tenant for every tenant table it uses
+func (aq *AuroraQuery) withTenant(query structs.Query) structs.Query {
+	tenant := aq.AuroraQueryBuilder.tenant
+	if tenant.disabled {
+		return query
+	}
+	dialect := aq.AuroraQueryBuilder.getDialect()
+
+	var mainTable string
+	switch aq.QueryType {
+	case DELETE:
+		mainTable = query.Delete
+	case UPDATE:
+		mainTable = query.Update.Table
+	case INSERT:
+		return aq.withInsertTenant(query)
+	default:
+		mainTable = query.From
+	}
+
+	if column, ok := registeredTable(tenantColumns, mainTable, dialect); ok {
+		query = withConditions(query, quoteIdentifier(tableAlias(mainTable)+"."+column, dialect)+" = "+aq.bindTenant(mainTable))
+	}
+
+	return withJoinConditions(query, func(join structs.Join) string {
+		column, ok := registeredTable(tenantColumns, join.SrcTable, dialect)
+		if !ok {
+			return ""
+		}
+
+		return quoteIdentifier(tableAlias(join.SrcTable)+"."+column, dialect) + " = " + aq.bindTenant(join.SrcTable)
+	})
+}
+
+//sets the tenant column of the inserted rows
+func (aq *AuroraQuery) withInsertTenant(query structs.Query) structs.Query {
+	dialect := aq.AuroraQueryBuilder.getDialect()
+	column, ok := registeredTable(tenantColumns, query.Insert.Into, dialect)
+	if !ok {
+		return query
+	}
+
+	placeholder := Expression(aq.bindTenant(query.Insert.Into))
+
+	columns := make([]string, 0, len(query.Insert.Columns)+1)
+	tenantIndex := -1
+	for i, insertColumn := range query.Insert.Columns {
+		if insertColumn == column {
+			tenantIndex = i
+		}
+		columns = append(columns, insertColumn)
+	}
+
+	if query.Insert.Select != nil && tenantIndex != -1 {
+		aq.err = ctxerror.New("the tenant column of an INSERT ... SELECT is set automatically, it must not be in the columns")
+		return query
+	}
+
+	if tenantIndex == -1 {
+		tenantIndex = len(columns)
+		columns = append(columns, column)
+	}
+	query.Insert.Columns = columns
+
+	//the conflicting row can belong to another tenant, it is only updated when it belongs to the bound tenant
+	if len(query.Insert.Updates) != 0 {
+		for _, update := range query.Insert.Updates {
+			if update.Column == column {
+				aq.err = ctxerror.New("the tenant column can not be updated by an upsert")
+				return query
+			}
+		}
+		query.Insert.UpdateCondition = quoteIdentifier(tableAlias(query.Insert.Into)+"."+column, dialect) + " = " + string(placeholder)
+	}
+
+	if query.Insert.Select != nil {
+		selectQuery := *query.Insert.Select
+		selectQuery.Select = append(selectQuery.Select[:len(selectQuery.Select):len(selectQuery.Select)], string(placeholder))
+		query.Insert.Select = &selectQuery
+		return query
+	}
+
+	values := make([][]interface{}, len(query.Insert.Values))
+	for i, row := range query.Insert.Values {
+		values[i] = make([]interface{}, len(columns))
+		copy(values[i], row)
+		values[i][tenantIndex] = placeholder
+	}
+	query.Insert.Values = values
+
+	return query
+}
+
+func (aq *AuroraQuery) bindTenant(table string) string {
+	tenant := aq.AuroraQueryBuilder.tenant
+	if !tenant.bound {
+		aq.err = ctxerror.SetContext(map[string]interface{}{
+			"table": table,
+		}).New("no tenant bound for a query on a tenant table, call ForTenant or WithoutTenant, or use Tenant(id).CreateQueryBuilder() and the Tenant(id) insert, update and delete helpers")
+	}
+
+	return aq.bindParameter(TENANT_PARAMETER, tenant.id)
+}
@@ -0,0 +1,163 @@
+package aurora
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTenantRendering(t *testing.T) {
+	RegisterTenantTable("orders", "tenant_id")
+	defer delete(tenantColumns, "orders")
+
+	tests := []struct {
+		name     string
+		builder  *AuroraQueryBuilder
+		expected string
+	}{
+		{
+			name:     "select",
+			builder:  CreateQueryBuilder().ForTenant(1).Select("id").From("orders o").Where("a = :a").OrWhere("b = :b"),
+			expected: "SELECT id FROM `orders` `o` WHERE (a = :a OR b = :b) AND `o`.`tenant_id` = :_tenant_id",
+		},
+		{
+			name:     "schema qualified select",
+			builder:  CreateQueryBuilder().ForTenant(1).Select("id").From("app.orders"),
+			expected: "SELECT id FROM `app`.`orders` WHERE `app`.`orders`.`tenant_id` = :_tenant_id",
+		},
+		{
+			name:     "mysql table names are compared without case",
+			builder:  CreateQueryBuilder().ForTenant(1).Select("id").From("Orders"),
+			expected: "SELECT id FROM `Orders` WHERE `Orders`.`tenant_id` = :_tenant_id",
+		},
+		{
+			name:     "quoted postgresql table names keep their case",
+			builder:  CreateQueryBuilder().Dialect(POSTGRESQL).ForTenant(1).Select("id").From(`"Orders"`),
+			expected: `SELECT id FROM "Orders"`,
+		},
+		{
+			name:     "join",
+			builder:  CreateQueryBuilder().ForTenant(1).Select("u.id").From("users u").Join("app.orders o", "u", "user_id", "id"),
+			expected: "SELECT u.id FROM `users` `u` JOIN `app`.`orders` `o` ON `o`.`user_id` = `u`.`id` AND `o`.`tenant_id` = :_tenant_id",
+		},
+		{
+			name:     "union",
+			builder:  CreateQueryBuilder().ForTenant(1).Select("id").From("users").Union(*CreateQueryBuilder().Select("id").From("orders")),
+			expected: "SELECT id FROM `users` UNION SELECT id FROM `orders` WHERE `orders`.`tenant_id` = :_tenant_id",
+		},
+		{
+			name:     "union member bound to the tenant",
+			builder:  CreateQueryBuilder().Select("id").From("users").Union(*CreateQueryBuilder().ForTenant(1).Select("id").From("orders")),
+			expected: "SELECT id FROM `users` UNION SELECT id FROM `orders` WHERE `orders`.`tenant_id` = :_tenant_id",
+		},
+		{
+			name:     "insert",
+			builder:  CreateQueryBuilder().ForTenant(1).Into("app.orders").Columns("id").Values(1),
+			expected: "INSERT INTO `app`.`orders` (`id`,`tenant_id`) VALUES (:insert_0_0,:_tenant_id)",
+		},
+		{
+			name:     "tenant scope insert",
+			builder:  Tenant(1).CreateQueryBuilder().Into("orders").InsertMode(INSERT_IGNORE).Columns("id").Values(1),
+			expected: "INSERT IGNORE INTO `orders` (`id`,`tenant_id`) VALUES (:insert_0_0,:_tenant_id)",
+		},
+		{
+			name:     "insert select",
+			builder:  CreateQueryBuilder().ForTenant(1).Into("orders").Columns("id").InsertSelect(*CreateQueryBuilder().Select("id").From("carts")),
+			expected: "INSERT INTO `orders` (`id`,`tenant_id`) SELECT id,:_tenant_id FROM `carts`",
+		},
+		{
+			name:     "mysql upsert",
+			builder:  CreateQueryBuilder().ForTenant(1).Into("orders").Columns("id", "total").Values(1, 2).OnDuplicateKeyUpdate("total"),
+			expected: "INSERT INTO `orders` (`id`,`total`,`tenant_id`) VALUES (:insert_0_0,:insert_0_1,:_tenant_id) ON DUPLICATE KEY UPDATE `total` = IF(`orders`.`tenant_id` = :_tenant_id, VALUES(`total`), `total`)",
+		},
+		{
+			name:     "postgresql upsert",
+			builder:  CreateQueryBuilder().Dialect(POSTGRESQL).ForTenant(1).Into("orders").Columns("id", "total").Values(1, 2).OnConflict("id").OnDuplicateKeyUpdate("total"),
+			expected: `INSERT INTO "orders" ("id","total","tenant_id") VALUES (:insert_0_0,:insert_0_1,:_tenant_id) ON CONFLICT ("id") DO UPDATE SET "total" = EXCLUDED."total" WHERE "orders"."tenant_id" = :_tenant_id`,
+		},
+		{
+			name:     "update",
+			builder:  CreateQueryBuilder().ForTenant(1).Update("app.orders").Set("total", 2).Where("id = :id").OrWhere("id = :other"),
+			expected: "UPDATE `app`.`orders` SET `total` = :set_0 WHERE (id = :id OR id = :other) AND `app`.`orders`.`tenant_id` = :_tenant_id",
+		},
+		{
+			name:     "delete",
+			builder:  CreateQueryBuilder().ForTenant(1).Delete("app.orders").Where("id = :id"),
+			expected: "DELETE FROM `app`.`orders` WHERE (id = :id) AND `app`.`orders`.`tenant_id` = :_tenant_id",
+		},
+		{
+			name:     "without tenant",
+			builder:  CreateQueryBuilder().WithoutTenant().Select("id").From("orders"),
+			expected: "SELECT id FROM `orders`",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := test.builder.SetParameters(map[string]interface{}{"id": 1, "other": 2, "a": 1, "b": 2}).GetQuery()
+			sql := normalizeSql(query.GetSql())
+			if err := query.check(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if sql != test.expected {
+				t.Errorf("got %s, expected %s", sql, test.expected)
+			}
+
+			if strings.Contains(sql, TENANT_PARAMETER) && query.Params()[TENANT_PARAMETER] != 1 {
+				t.Errorf("tenant parameter is %v, expected 1", query.Params()[TENANT_PARAMETER])
+			}
+		})
+	}
+}
+
+func TestTenantErrors(t *testing.T) {
+	RegisterTenantTable("orders", "tenant_id")
+	defer delete(tenantColumns, "orders")
+
+	tests := []struct {
+		name     string
+		builder  *AuroraQueryBuilder
+		expected string
+	}{
+		{
+			name:     "no tenant bound",
+			builder:  CreateQueryBuilder().Select("id").From("app.orders"),
+			expected: "no tenant bound",
+		},
+		{
+			name:     "no tenant bound for a join",
+			builder:  CreateQueryBuilder().Select("u.id").From("users u").Join("orders o", "u", "user_id", "id"),
+			expected: "no tenant bound",
+		},
+		{
+			name:     "tenant parameter given",
+			builder:  CreateQueryBuilder().ForTenant(1).Select("id").From("orders").SetParameters(map[string]interface{}{TENANT_PARAMETER: 2}),
+			expected: "can not be given",
+		},
+		{
+			name:     "tenant column updated by an upsert",
+			builder:  CreateQueryBuilder().ForTenant(1).Into("orders").Columns("id").Values(1).OnDuplicateKeyUpdate("tenant_id"),
+			expected: "tenant column can not be updated",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query := test.builder.GetQuery()
+			query.GetSql()
+			err := query.check()
+			if err == nil {
+				t.Fatalf("expected error %q", test.expected)
+			}
+
+			if !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("got error %q, expected %q", err.Error(), test.expected)
+			}
+		})
+	}
+}
+
+//collapses the spaces of the generated sql
+func normalizeSql(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
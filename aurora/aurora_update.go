@@ -2,53 +2,73 @@ package aurora
 
 import (
 	"github.com/mmatagrin/ctxerror"
+	"github.com/mmatagrin/sql-builder/structs"
 	)
 
 type AuroraUpdateStruct struct {
-	sqlStr string
-	where  []string
+	builder    AuroraQueryBuilder
+	parameters map[string]interface{}
 }
 
+//expressions are whole assignments, ex: "name = :name"
 func AuroraUpdate(table string, expressions []string) *AuroraUpdateStruct {
-	var sqlStr string
-
-	sqlStr = "UPDATE " + table + " SET "
+	builder := AuroraQueryBuilder{}
+	builder.Update(table)
 
 	for _, expression := range expressions {
-		sqlStr += expression + ","
+		builder.query.Update.Set = append(builder.query.Update.Set, structs.Assignment{Expression: expression})
 	}
-	sqlStr = sqlStr[:len(sqlStr)-1]
 
-	return &AuroraUpdateStruct{sqlStr: sqlStr}
+	return &AuroraUpdateStruct{builder: builder}
 }
 
 func (mu *AuroraUpdateStruct) Where(condition string) *AuroraUpdateStruct {
-	mu.where = append(mu.where, condition)
+	mu.builder.Where(condition)
+	return mu
+}
+
+//default parameters of the update, the values given to ExecuteUpdate take precedence
+func (mu *AuroraUpdateStruct) SetParameters(parameters map[string]interface{}) *AuroraUpdateStruct {
+	mu.parameters = parameters
 	return mu
 }
 
+func (mu *AuroraUpdateStruct) GetSql() string {
+	return mu.getQuery(nil).GetSql()
+}
+
+func (mu *AuroraUpdateStruct) Params() map[string]interface{} {
+	return mu.getQuery(nil).Params()
+}
+
+//the update can be executed several times, with different values
 func (mu *AuroraUpdateStruct) ExecuteUpdate(connexion AuroraConnexion, values map[string]interface{}, transactionId *string) (int64, error) {
 	context := ctxerror.SetContext(map[string]interface{}{
 		"connexion": connexion,
-		"values": values,
+		"values": RedactParameters(values),
 	})
 
-	for index, condition := range mu.where {
-		if index == 0 {
-			mu.sqlStr += " WHERE " + condition
-		} else {
-			mu.sqlStr += " AND " + condition
-		}
-	}
-
-	res, err := PerformAuroraQuery(mu.sqlStr, values, connexion, transactionId)
+	res, err := mu.getQuery(values).Execute(connexion, transactionId)
 	if err != nil {
 		return 0, context.Wrap(err, "unable to perform update query")
 	}
 
-	if res.NumberOfRecordsUpdated != nil {
-		return *res.NumberOfRecordsUpdated, nil
+	return  res, nil
+}
+
+//a new query is built every time so that rendering it never alters the update
+func (mu *AuroraUpdateStruct) getQuery(values map[string]interface{}) *AuroraQuery {
+	builder := mu.builder
+	return builder.GetQuery().SetParameters(mergeParameters(mu.parameters, values))
+}
+
+func mergeParameters(parameters ...map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{})
+	for _, params := range parameters {
+		for key, value := range params {
+			merged[key] = value
+		}
 	}
 
-	return  0, nil
+	return merged
 }
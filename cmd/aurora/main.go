@@ -0,0 +1,258 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/rdsdataservice"
+	"github.com/mmatagrin/sql-builder/aurora"
+	"github.com/mmatagrin/sql-builder/migrations"
+	"os"
+	"strings"
+)
+
+const usage = `usage: aurora <command> [flags] [arguments]
+
+commands:
+  query [-format table|csv|json] <sql>   runs a statement and prints its results
+  exec [-separator ;] <file>             runs a sql script in a transaction
+  migrate [-dir migrations] up           applies the pending migrations
+  migrate [-dir migrations] down [-steps 1]
+                                         reverts the last applied migrations
+  migrate [-dir migrations] status       lists the migrations and their state
+
+the connexion flags default to the environment variables
+AURORA_RESOURCE_ARN, AURORA_SECRET_ARN, AURORA_DATABASE, AURORA_ENDPOINT and AWS_REGION
+`
+
+type config struct {
+	connexion aurora.AuroraConnexion
+	endpoint  string
+	region    string
+	dialect   string
+	session   *session.Session
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "query":
+		err = runQuery(os.Args[2:])
+	case "exec":
+		err = runExec(os.Args[2:])
+	case "migrate":
+		err = runMigrate(os.Args[2:])
+	case "help", "-h", "-help", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %s\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+//flag set with the connexion flags shared by every command
+func newFlagSet(name string, cfg *config) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprint(os.Stderr, usage+"\nflags:\n")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(&cfg.connexion.ResourceArn, "resource-arn", os.Getenv("AURORA_RESOURCE_ARN"), "arn of the aurora cluster")
+	flags.StringVar(&cfg.connexion.SecretArn, "secret-arn", os.Getenv("AURORA_SECRET_ARN"), "arn of the secret holding the database credentials")
+	flags.StringVar(&cfg.connexion.Database, "database", os.Getenv("AURORA_DATABASE"), "database name")
+	flags.StringVar(&cfg.endpoint, "endpoint", os.Getenv("AURORA_ENDPOINT"), "url of the Data API, to target a local stand-in")
+	flags.StringVar(&cfg.region, "region", os.Getenv("AWS_REGION"), "aws region")
+	flags.StringVar(&cfg.dialect, "dialect", string(aurora.MYSQL), "sql dialect, mysql or postgresql")
+
+	return flags
+}
+
+//parses the flags placed before and after the positional arguments, flag.FlagSet stops at the first positional one.
+//returns the positional arguments, everything after -- is positional
+func parseFlags(flags *flag.FlagSet, args []string) []string {
+	arguments := []string{}
+	for {
+		flags.Parse(args)
+		rest := flags.Args()
+		if len(rest) == 0 {
+			return arguments
+		}
+
+		if len(rest) < len(args) && args[len(args)-len(rest)-1] == "--" {
+			return append(arguments, rest...)
+		}
+
+		arguments = append(arguments, rest[0])
+		args = rest[1:]
+	}
+}
+
+//creates the aws session and sets the default dialect
+func (cfg *config) init() error {
+	if cfg.connexion.ResourceArn == "" || cfg.connexion.SecretArn == "" {
+		return fmt.Errorf("the resource and secret arns are required")
+	}
+
+	dialect := aurora.Dialect(strings.ToLower(cfg.dialect))
+	if dialect != aurora.MYSQL && dialect != aurora.POSTGRESQL {
+		return fmt.Errorf("unknown dialect %s", cfg.dialect)
+	}
+	aurora.SetDefaultDialect(dialect)
+
+	awsConfig := aws.Config{}
+	if cfg.endpoint != "" {
+		awsConfig.Endpoint = aws.String(cfg.endpoint)
+	}
+	if cfg.region != "" {
+		awsConfig.Region = aws.String(cfg.region)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            awsConfig,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return err
+	}
+	aurora.SetAwsSession(*sess)
+	cfg.session = sess
+
+	return nil
+}
+
+func runQuery(args []string) error {
+	cfg := &config{}
+	flags := newFlagSet("query", cfg)
+	format := flags.String("format", "table", "output format, table, csv or json")
+	arguments := parseFlags(flags, args)
+
+	if len(arguments) != 1 {
+		return fmt.Errorf("query expects one sql statement")
+	}
+
+	if err := cfg.init(); err != nil {
+		return err
+	}
+
+	writer, err := newResultWriter(*format, os.Stdout)
+	if err != nil {
+		return err
+	}
+
+	//the results metadata gives the names of the columns of an ad-hoc statement
+	res, err := rdsdataservice.New(cfg.session).ExecuteStatement(&rdsdataservice.ExecuteStatementInput{
+		Database:              aws.String(cfg.connexion.Database),
+		ResourceArn:           aws.String(cfg.connexion.ResourceArn),
+		SecretArn:             aws.String(cfg.connexion.SecretArn),
+		Sql:                   aws.String(arguments[0]),
+		IncludeResultMetadata: aws.Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(res.ColumnMetadata) == 0 {
+		fmt.Printf("%d rows affected\n", aws.Int64Value(res.NumberOfRecordsUpdated))
+		return nil
+	}
+
+	fields := make([]string, len(res.ColumnMetadata))
+	for i, column := range res.ColumnMetadata {
+		fields[i] = aws.StringValue(column.Label)
+		if fields[i] == "" {
+			fields[i] = aws.StringValue(column.Name)
+		}
+	}
+
+	results, err := aurora.ParseResults(fields, res.Records)
+	if err != nil {
+		return err
+	}
+
+	return writer(fields, results)
+}
+
+func runExec(args []string) error {
+	cfg := &config{}
+	flags := newFlagSet("exec", cfg)
+	separator := flags.String("separator", ";", "initial statement delimiter, the script can change it with DELIMITER")
+	arguments := parseFlags(flags, args)
+
+	if len(arguments) != 1 {
+		return fmt.Errorf("exec expects one script file")
+	}
+
+	if err := cfg.init(); err != nil {
+		return err
+	}
+
+	if err := aurora.ExecuteFile(arguments[0], *separator, cfg.connexion); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func runMigrate(args []string) error {
+	cfg := &config{}
+	flags := newFlagSet("migrate", cfg)
+	dir := flags.String("dir", "migrations", "directory of the migration files")
+	table := flags.String("table", migrations.DEFAULT_TABLE, "table of the applied migrations")
+	dryRun := flags.Bool("dry-run", false, "print the statements instead of executing them")
+	steps := flags.Int("steps", 1, "number of migrations reverted by down")
+	arguments := parseFlags(flags, args)
+
+	if len(arguments) != 1 {
+		return fmt.Errorf("migrate expects up, down or status")
+	}
+
+	if err := cfg.init(); err != nil {
+		return err
+	}
+
+	loaded, err := migrations.Load(*dir)
+	if err != nil {
+		return err
+	}
+
+	migrator := migrations.NewMigrator(cfg.connexion, loaded)
+	migrator.Table = *table
+	migrator.DryRun = *dryRun
+
+	switch arguments[0] {
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migrations applied\n", len(applied))
+	case "down":
+		reverted, err := migrator.Down(*steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d migrations reverted\n", len(reverted))
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		return writeStatuses(statuses, os.Stdout)
+	default:
+		return fmt.Errorf("unknown migrate command %s", arguments[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/mmatagrin/sql-builder/aurora"
+	"github.com/mmatagrin/sql-builder/migrations"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+type resultWriter func(fields []string, results []aurora.QueryResult) error
+
+func newResultWriter(format string, output io.Writer) (resultWriter, error) {
+	switch format {
+	case "table":
+		return func(fields []string, results []aurora.QueryResult) error {
+			return writeTable(fields, results, output)
+		}, nil
+	case "csv":
+		return func(fields []string, results []aurora.QueryResult) error {
+			return writeCsv(fields, results, output)
+		}, nil
+	case "json":
+		return func(fields []string, results []aurora.QueryResult) error {
+			encoder := json.NewEncoder(output)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(results)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %s", format)
+	}
+}
+
+func writeTable(fields []string, results []aurora.QueryResult, output io.Writer) error {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	writeTableRow(writer, fields)
+	for _, result := range results {
+		writeTableRow(writer, formatRow(fields, result, "NULL"))
+	}
+	fmt.Fprintf(writer, "(%d rows)\n", len(results))
+
+	return writer.Flush()
+}
+
+func writeTableRow(writer io.Writer, values []string) {
+	for i, value := range values {
+		if i != 0 {
+			fmt.Fprint(writer, "\t")
+		}
+		fmt.Fprint(writer, value)
+	}
+	fmt.Fprint(writer, "\n")
+}
+
+func writeCsv(fields []string, results []aurora.QueryResult, output io.Writer) error {
+	writer := csv.NewWriter(output)
+	if err := writer.Write(fields); err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if err := writer.Write(formatRow(fields, result, "")); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+
+	return writer.Error()
+}
+
+//values of the row in the order of fields, blobs are written in hexadecimal
+func formatRow(fields []string, result aurora.QueryResult, null string) []string {
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		switch value := result[field].(type) {
+		case nil:
+			values[i] = null
+		case []byte:
+			values[i] = "0x" + hex.EncodeToString(value)
+		default:
+			values[i] = fmt.Sprint(value)
+		}
+	}
+
+	return values
+}
+
+func writeStatuses(statuses []migrations.MigrationStatus, output io.Writer) error {
+	writer := tabwriter.NewWriter(output, 0, 0, 2, ' ', 0)
+	writeTableRow(writer, []string{"version", "name", "status", "applied_at"})
+	for _, status := range statuses {
+		state := "pending"
+		switch {
+		case status.Missing:
+			state = "missing files"
+		case status.Edited:
+			state = "edited"
+		case status.Applied:
+			state = "applied"
+		}
+
+		writeTableRow(writer, []string{strconv.FormatInt(status.Version, 10), status.Name, state, status.AppliedAt})
+	}
+
+	return writer.Flush()
+}
@@ -0,0 +1,106 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/mmatagrin/ctxerror"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+//versioned sql migration, loaded from the files <version>_<name>.up.sql and <version>_<name>.down.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	//empty when the migration has no down file
+	Down string
+	//sha256 of the up file, stored when the migration is applied to detect edited migrations
+	Checksum string
+}
+
+var migrationFileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+//loads the migrations of dir, sorted by version. the files not named like a migration are ignored
+func Load(dir string) ([]Migration, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, ctxerror.SetContext(map[string]interface{}{
+			"dir": dir,
+		}).Wrap(err, "unable to read migrations directory")
+	}
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+
+	return load(names, func(name string) ([]byte, error) {
+		return ioutil.ReadFile(filepath.Join(dir, name))
+	})
+}
+
+func load(names []string, readFile func(name string) ([]byte, error)) ([]Migration, error) {
+	migrations := make(map[int64]*Migration)
+	for _, fileName := range names {
+		matches := migrationFileName.FindStringSubmatch(fileName)
+		if matches == nil {
+			continue
+		}
+
+		context := ctxerror.SetContext(map[string]interface{}{
+			"file": fileName,
+		})
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			return nil, context.Wrap(err, "invalid migration version")
+		}
+
+		content, err := readFile(fileName)
+		if err != nil {
+			return nil, context.Wrap(err, "unable to read migration file")
+		}
+
+		migration, found := migrations[version]
+		if !found {
+			migration = &Migration{Version: version, Name: matches[2]}
+			migrations[version] = migration
+		}
+
+		if migration.Name != matches[2] {
+			context.AddContext("name", migration.Name)
+			return nil, context.New("two migrations have the version " + matches[1])
+		}
+
+		if matches[3] == "up" {
+			checksum := sha256.Sum256(content)
+			migration.Up = string(content)
+			migration.Checksum = hex.EncodeToString(checksum[:])
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	sorted := make([]Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		if migration.Checksum == "" {
+			return nil, ctxerror.SetContext(map[string]interface{}{
+				"version": migration.Version,
+				"name": migration.Name,
+			}).New("migration has no up file")
+		}
+		sorted = append(sorted, *migration)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Version < sorted[j].Version
+	})
+
+	return sorted, nil
+}
@@ -0,0 +1,31 @@
+//go:build go1.16
+// +build go1.16
+
+package migrations
+
+import (
+	"github.com/mmatagrin/ctxerror"
+	"io/fs"
+	"path"
+)
+
+//loads the migrations of the directory dir of fsys (an embed.FS...), see Load
+func LoadFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, ctxerror.SetContext(map[string]interface{}{
+			"dir": dir,
+		}).Wrap(err, "unable to read migrations directory")
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return load(names, func(name string) ([]byte, error) {
+		return fs.ReadFile(fsys, path.Join(dir, name))
+	})
+}
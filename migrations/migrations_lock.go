@@ -0,0 +1,133 @@
+package migrations
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/mmatagrin/ctxerror"
+	"github.com/mmatagrin/sql-builder/aurora"
+	"strconv"
+	"time"
+)
+
+//the migrations lock is a row of the lock table, it does not depend on a connection or a transaction that the Data API
+//could close while a migration runs. the row expires when it is not refreshed, so that the lock of a crashed migrator
+//is released
+type migrationsLock struct {
+	owner     string
+	refreshed time.Time
+}
+
+func (m *Migrator) lockTable() string {
+	return aurora.Identifier(m.Table + "_lock").Quote(m.Dialect)
+}
+
+func (m *Migrator) createLockTable() error {
+	sqlStr := "CREATE TABLE IF NOT EXISTS " + m.lockTable() + " (" +
+		"id INT NOT NULL PRIMARY KEY, " +
+		"owner CHAR(32) NOT NULL, " +
+		"expires_at TIMESTAMP NOT NULL)"
+
+	if _, err := aurora.PerformAuroraQuery(sqlStr, nil, m.Connexion, nil); err != nil {
+		return ctxerror.Wrap(err, "unable to create migrations lock table")
+	}
+
+	return nil
+}
+
+//takes the lock, waiting up to LockTimeout seconds for the lock held by another migrator
+func (m *Migrator) lock() error {
+	owner := make([]byte, 16)
+	if _, err := rand.Read(owner); err != nil {
+		return ctxerror.Wrap(err, "unable to generate migrations lock owner")
+	}
+	m.migrationsLock = migrationsLock{owner: hex.EncodeToString(owner)}
+
+	if m.LockExpiry <= 0 {
+		m.LockExpiry = DEFAULT_LOCK_EXPIRY
+	}
+
+	context := ctxerror.SetContext(map[string]interface{}{
+		"lock": m.Table + "_lock",
+	})
+
+	parameters := map[string]interface{}{
+		"owner": m.migrationsLock.owner,
+		"expiry": int64(m.LockExpiry),
+	}
+
+	deadline := time.Now().Add(time.Duration(m.LockTimeout) * time.Second)
+	for {
+		_, err := aurora.PerformAuroraQuery("DELETE FROM "+m.lockTable()+" WHERE id = 1 AND expires_at < CURRENT_TIMESTAMP", nil, m.Connexion, nil)
+		if err != nil {
+			return context.Wrap(err, "unable to release expired migrations lock")
+		}
+
+		insertVerb := "INSERT IGNORE INTO "
+		conflict := ""
+		if m.Dialect == aurora.POSTGRESQL {
+			insertVerb = "INSERT INTO "
+			conflict = " ON CONFLICT DO NOTHING"
+		}
+
+		res, err := aurora.PerformAuroraQuery(insertVerb+m.lockTable()+" (id, owner, expires_at) VALUES (1, :owner, "+m.lockExpiry()+")"+conflict, parameters, m.Connexion, nil)
+		if err != nil {
+			return context.Wrap(err, "unable to acquire migrations lock")
+		}
+
+		if res.NumberOfRecordsUpdated != nil && *res.NumberOfRecordsUpdated == 1 {
+			m.migrationsLock.refreshed = time.Now()
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			return context.New("unable to acquire migrations lock, another migration is running")
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+//pushes the expiry of the lock back, fails when the lock expired and may have been taken by another migrator.
+//the lock is refreshed at most every half expiry, the new expiry is always later than the stored one
+func (m *Migrator) refreshLock() error {
+	if m.migrationsLock.owner == "" || time.Since(m.migrationsLock.refreshed) < time.Duration(m.LockExpiry)*time.Second/2 {
+		return nil
+	}
+
+	res, err := aurora.PerformAuroraQuery("UPDATE "+m.lockTable()+" SET expires_at = "+m.lockExpiry()+" WHERE id = 1 AND owner = :owner", map[string]interface{}{
+		"owner": m.migrationsLock.owner,
+		"expiry": int64(m.LockExpiry),
+	}, m.Connexion, nil)
+	if err != nil {
+		return ctxerror.Wrap(err, "unable to refresh migrations lock")
+	}
+
+	if res.NumberOfRecordsUpdated == nil || *res.NumberOfRecordsUpdated == 0 {
+		return ctxerror.New("migrations lock expired after " + strconv.Itoa(m.LockExpiry) + " seconds, another migration may be running")
+	}
+
+	m.migrationsLock.refreshed = time.Now()
+	return nil
+}
+
+func (m *Migrator) unlock() error {
+	if m.migrationsLock.owner == "" {
+		return nil
+	}
+
+	_, err := aurora.PerformAuroraQuery("DELETE FROM "+m.lockTable()+" WHERE id = 1 AND owner = :owner", map[string]interface{}{
+		"owner": m.migrationsLock.owner,
+	}, m.Connexion, nil)
+	m.migrationsLock = migrationsLock{}
+
+	return err
+}
+
+//CURRENT_TIMESTAMP plus the :expiry seconds
+func (m *Migrator) lockExpiry() string {
+	if m.Dialect == aurora.POSTGRESQL {
+		return "CURRENT_TIMESTAMP + make_interval(secs => :expiry)"
+	}
+
+	return "DATE_ADD(CURRENT_TIMESTAMP, INTERVAL :expiry SECOND)"
+}
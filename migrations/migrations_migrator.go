@@ -0,0 +1,473 @@
+package migrations
+
+import (
+	"github.com/mmatagrin/ctxerror"
+	"github.com/mmatagrin/sql-builder/aurora"
+	"github.com/mmatagrin/sql-builder/structs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DEFAULT_TABLE = "schema_migrations"
+	//seconds waited for the lock held by another migrator
+	DEFAULT_LOCK_TIMEOUT = 30
+	//seconds after which the lock of a migrator that stopped refreshing it, ex: a crashed one, is released
+	DEFAULT_LOCK_EXPIRY = 300
+)
+
+type Migrator struct {
+	Connexion  aurora.AuroraConnexion
+	Migrations []Migration
+	Dialect    aurora.Dialect
+	//table of the applied migrations
+	Table       string
+	LockTimeout int
+	LockExpiry  int
+	//the statements are logged instead of being executed, nothing is written to the database
+	DryRun bool
+	//logs the migrations run, the standard log package is used when nil
+	Logger         *log.Logger
+	migrationsLock migrationsLock
+}
+
+//state of a migration, for the migrations found in the files or in the table of the applied migrations
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt string
+	//the up file changed since the migration was applied
+	Edited bool
+	//applied, but its files are missing
+	Missing bool
+}
+
+type appliedMigration struct {
+	version   int64
+	name      string
+	checksum  string
+	appliedAt string
+}
+
+func NewMigrator(connexion aurora.AuroraConnexion, migrations []Migration) *Migrator {
+	return &Migrator{
+		Connexion:   connexion,
+		Migrations:  migrations,
+		Dialect:     aurora.GetDefaultDialect(),
+		Table:       DEFAULT_TABLE,
+		LockTimeout: DEFAULT_LOCK_TIMEOUT,
+		LockExpiry:  DEFAULT_LOCK_EXPIRY,
+	}
+}
+
+//applies the pending migrations, and returns them. each migration is recorded right after its statements.
+//postgresql applies them in a single transaction, rolled back on error. mysql implicitly commits on every DDL statement,
+//so each migration is committed with its record instead: on error the migrations applied before stay applied, and the
+//DDL statements of the failing migration run before the error are not rolled back
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.run(func(transactionId string, applied map[int64]appliedMigration) ([]Migration, error) {
+		migrations := []Migration{}
+		for _, migration := range m.sortedMigrations() {
+			if _, found := applied[migration.Version]; found {
+				continue
+			}
+
+			m.logf("applying migration %d %s", migration.Version, migration.Name)
+			err := m.step(transactionId, func(transactionId string) error {
+				if err := m.execute(migration.Up, transactionId); err != nil {
+					return ctxerror.SetContext(map[string]interface{}{
+						"version": migration.Version,
+						"name": migration.Name,
+					}).Wrap(err, "unable to apply migration")
+				}
+
+				if !m.DryRun {
+					_, err := aurora.CreateQueryBuilder().Dialect(m.Dialect).Into(m.Table).
+						Columns("version", "name", "checksum").
+						Values(migration.Version, migration.Name, migration.Checksum).
+						GetQuery().Execute(m.Connexion, &transactionId)
+					if err != nil {
+						return ctxerror.Wrap(err, "unable to record migration")
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			migrations = append(migrations, migration)
+		}
+
+		return migrations, nil
+	})
+}
+
+//reverts the last steps applied migrations, and returns them. the transactions are the same as for Up: on mysql the
+//migrations reverted before an error stay reverted
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, ctxerror.New("Down expects at least one step")
+	}
+
+	return m.run(func(transactionId string, applied map[int64]appliedMigration) ([]Migration, error) {
+		versions := make([]int64, 0, len(applied))
+		for version := range applied {
+			versions = append(versions, version)
+		}
+		sort.Slice(versions, func(i, j int) bool {
+			return versions[i] > versions[j]
+		})
+
+		if steps > len(versions) {
+			steps = len(versions)
+		}
+
+		sources := m.migrationsByVersion()
+		migrations := []Migration{}
+		for _, version := range versions[:steps] {
+			context := ctxerror.SetContext(map[string]interface{}{
+				"version": version,
+				"name": applied[version].name,
+			})
+
+			migration, found := sources[version]
+			if !found {
+				return nil, context.New("files of the migration are missing")
+			}
+
+			if strings.TrimSpace(migration.Down) == "" {
+				return nil, context.New("migration has no down file")
+			}
+
+			m.logf("reverting migration %d %s", migration.Version, migration.Name)
+			err := m.step(transactionId, func(transactionId string) error {
+				if err := m.execute(migration.Down, transactionId); err != nil {
+					return context.Wrap(err, "unable to revert migration")
+				}
+
+				if !m.DryRun {
+					_, err := aurora.CreateQueryBuilder().Dialect(m.Dialect).Delete(m.Table).
+						Where("version = :version").
+						SetParameters(map[string]interface{}{"version": version}).
+						GetQuery().Execute(m.Connexion, &transactionId)
+					if err != nil {
+						return context.Wrap(err, "unable to remove migration")
+					}
+				}
+
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			migrations = append(migrations, migration)
+		}
+
+		return migrations, nil
+	})
+}
+
+//state of every migration, sorted by version
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.appliedIfExists()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []MigrationStatus{}
+	sources := m.migrationsByVersion()
+	for _, migration := range m.sortedMigrations() {
+		status := MigrationStatus{
+			Version: migration.Version,
+			Name:    migration.Name,
+		}
+
+		if appliedMigration, found := applied[migration.Version]; found {
+			status.Applied = true
+			status.AppliedAt = appliedMigration.appliedAt
+			status.Edited = appliedMigration.checksum != migration.Checksum
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	for version, appliedMigration := range applied {
+		if _, found := sources[version]; !found {
+			statuses = append(statuses, MigrationStatus{
+				Version:   version,
+				Name:      appliedMigration.name,
+				Applied:   true,
+				AppliedAt: appliedMigration.appliedAt,
+				Missing:   true,
+			})
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+//runs apply holding the migrations lock, after checking that no applied migration was edited. on postgresql
+//transactionId is the transaction of the whole run, rolled back on error. on mysql it is empty: see step.
+//a dry run writes nothing, the tables are not created and the lock is not taken
+func (m *Migrator) run(apply func(transactionId string, applied map[int64]appliedMigration) ([]Migration, error)) (migrations []Migration, e error) {
+	if m.DryRun {
+		applied, err := m.appliedIfExists()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := m.checkEdited(applied); err != nil {
+			return nil, err
+		}
+
+		return apply("", applied)
+	}
+
+	if err := m.createTable(); err != nil {
+		return nil, err
+	}
+
+	if err := m.lock(); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if errUnlock := m.unlock(); errUnlock != nil {
+			if e == nil {
+				e = ctxerror.Wrap(errUnlock, "unable to release migrations lock")
+			} else {
+				e = ctxerror.Wrap(e, "migration failed").AddError(errUnlock, "unable to release migrations lock")
+			}
+		}
+	}()
+
+	var transactionId string
+	var runTransactionId *string
+	if m.Dialect == aurora.POSTGRESQL {
+		var err error
+		transactionId, err = aurora.BeginTransaction(m.Connexion)
+		if err != nil {
+			return nil, ctxerror.Wrap(err, "unable to start database transaction")
+		}
+		runTransactionId = &transactionId
+
+		defer func() {
+			if e == nil {
+				if errCommit := aurora.CommitTransaction(m.Connexion, transactionId); errCommit != nil {
+					e = ctxerror.Wrap(errCommit, "unable to commit transaction")
+				}
+
+				return
+			}
+
+			if errRollback := aurora.RollbackTransaction(m.Connexion, transactionId); errRollback != nil {
+				e = ctxerror.Wrap(e, "migration failed").AddError(errRollback, "unable to rollback transaction")
+			}
+		}()
+	}
+
+	applied, err := m.applied(runTransactionId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.checkEdited(applied); err != nil {
+		return nil, err
+	}
+
+	return apply(transactionId, applied)
+}
+
+//runs migrate, which applies or reverts a single migration and records it. postgresql runs it in the transaction of
+//the whole run. mysql implicitly commits on every DDL statement, a rollback could only undo the record, so it runs in
+//its own transaction, committed with the record
+func (m *Migrator) step(transactionId string, migrate func(transactionId string) error) error {
+	if m.Dialect == aurora.POSTGRESQL || m.DryRun {
+		return migrate(transactionId)
+	}
+
+	stepTransactionId, err := aurora.BeginTransaction(m.Connexion)
+	if err != nil {
+		return ctxerror.Wrap(err, "unable to start database transaction")
+	}
+
+	if err := migrate(stepTransactionId); err != nil {
+		if errRollback := aurora.RollbackTransaction(m.Connexion, stepTransactionId); errRollback != nil {
+			return ctxerror.Wrap(err, "migration failed").AddError(errRollback, "unable to rollback transaction")
+		}
+
+		return err
+	}
+
+	if err := aurora.CommitTransaction(m.Connexion, stepTransactionId); err != nil {
+		return ctxerror.Wrap(err, "unable to commit transaction")
+	}
+
+	return nil
+}
+
+func (m *Migrator) createTable() error {
+	sqlStr := "CREATE TABLE IF NOT EXISTS " + aurora.Identifier(m.Table).Quote(m.Dialect) + " (" +
+		"version BIGINT NOT NULL PRIMARY KEY, " +
+		"name VARCHAR(255) NOT NULL, " +
+		"checksum CHAR(64) NOT NULL, " +
+		"applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)"
+
+	if _, err := aurora.PerformAuroraQuery(sqlStr, nil, m.Connexion, nil); err != nil {
+		return ctxerror.Wrap(err, "unable to create migrations table")
+	}
+
+	return m.createLockTable()
+}
+
+//applied migrations, none when the table of the applied migrations does not exist yet
+func (m *Migrator) appliedIfExists() (map[int64]appliedMigration, error) {
+	exists, err := m.tableExists()
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return map[int64]appliedMigration{}, nil
+	}
+
+	return m.applied(nil)
+}
+
+func (m *Migrator) tableExists() (bool, error) {
+	schema := "DATABASE()"
+	table := m.Table
+	if m.Dialect == aurora.POSTGRESQL {
+		//the table is created with an unquoted, so folded, name
+		schema = "current_schema()"
+		table = strings.ToLower(table)
+	}
+
+	parameters := map[string]interface{}{
+		"table": table,
+	}
+	if index := strings.LastIndexByte(table, '.'); index != -1 {
+		schema = ":schema"
+		parameters["schema"] = table[:index]
+		parameters["table"] = table[index+1:]
+	}
+
+	res, err := aurora.PerformAuroraQuery("SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = "+schema+" AND table_name = :table", parameters, m.Connexion, nil)
+	if err != nil {
+		return false, ctxerror.Wrap(err, "unable to check the migrations table")
+	}
+
+	return len(res.Records) != 0 && len(res.Records[0]) != 0 && res.Records[0][0].LongValue != nil && *res.Records[0][0].LongValue != 0, nil
+}
+
+func (m *Migrator) applied(transactionId *string) (map[int64]appliedMigration, error) {
+	results, err := aurora.CreateQueryBuilder().Dialect(m.Dialect).
+		Select("version", "name", "checksum", "applied_at").
+		From(m.Table).
+		OrderBy(structs.OrderBy{Field: "version", Order: structs.ASC}).
+		GetQuery().GetResults(m.Connexion, transactionId)
+	if err != nil {
+		return nil, ctxerror.Wrap(err, "unable to read applied migrations")
+	}
+
+	applied := make(map[int64]appliedMigration, len(results))
+	for _, result := range results {
+		version, _ := result["version"].(int64)
+		name, _ := result["name"].(string)
+		checksum, _ := result["checksum"].(string)
+		appliedAt, _ := result["applied_at"].(string)
+		applied[version] = appliedMigration{
+			version:   version,
+			name:      name,
+			checksum:  checksum,
+			appliedAt: appliedAt,
+		}
+	}
+
+	return applied, nil
+}
+
+//applied migrations must not be edited, the change would never be applied to the databases already migrated
+func (m *Migrator) checkEdited(applied map[int64]appliedMigration) error {
+	edited := []string{}
+	for _, migration := range m.sortedMigrations() {
+		appliedMigration, found := applied[migration.Version]
+		if found && appliedMigration.checksum != migration.Checksum {
+			edited = append(edited, strconv.FormatInt(migration.Version, 10)+"_"+migration.Name)
+		}
+	}
+
+	if len(edited) != 0 {
+		return ctxerror.SetContext(map[string]interface{}{
+			"migrations": edited,
+		}).New("migrations edited after being applied: " + strings.Join(edited, ", "))
+	}
+
+	return nil
+}
+
+func (m *Migrator) execute(script string, transactionId string) error {
+	statements, err := aurora.SplitScript(strings.NewReader(script), ";", m.Dialect)
+	if err != nil {
+		return ctxerror.Wrap(err, "unable to parse migration")
+	}
+
+	for _, statement := range statements {
+		if m.DryRun {
+			m.logf("%s;", statement.Sql)
+			continue
+		}
+
+		if err := m.refreshLock(); err != nil {
+			return err
+		}
+
+		_, err := aurora.PerformAuroraQuery(statement.Sql, nil, m.Connexion, &transactionId)
+		if err != nil {
+			return ctxerror.SetContext(map[string]interface{}{
+				"current_query": statement.Sql,
+				"line":          statement.Line,
+			}).Wrap(err, "unable to perform query at line "+strconv.Itoa(statement.Line))
+		}
+	}
+
+	return nil
+}
+
+func (m *Migrator) sortedMigrations() []Migration {
+	migrations := make([]Migration, len(m.Migrations))
+	copy(migrations, m.Migrations)
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations
+}
+
+func (m *Migrator) migrationsByVersion() map[int64]Migration {
+	migrations := make(map[int64]Migration, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		migrations[migration.Version] = migration
+	}
+
+	return migrations
+}
+
+func (m *Migrator) logf(format string, args ...interface{}) {
+	if m.Logger != nil {
+		m.Logger.Printf(format, args...)
+		return
+	}
+
+	log.Printf(format, args...)
+}
@@ -0,0 +1,18 @@
+module github.com/mmatagrin/sql-builder/otelaurora
+
+go 1.26.0
+
+require (
+	github.com/mmatagrin/sql-builder v0.0.0
+	go.opentelemetry.io/otel v1.47.0
+	go.opentelemetry.io/otel/trace v1.47.0
+)
+
+require (
+	github.com/aws/aws-sdk-go v1.34.5 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/jmespath/go-jmespath v0.3.0 // indirect
+	github.com/mmatagrin/ctxerror v0.0.0-20200813181857-5f4e18911605 // indirect
+)
+
+replace github.com/mmatagrin/sql-builder => ../
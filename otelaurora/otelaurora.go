@@ -0,0 +1,138 @@
+//Package otelaurora creates OpenTelemetry spans for the Data API calls of the aurora package:
+//
+//	aurora.AddQueryHook(otelaurora.NewTracingHook(otel.GetTracerProvider()))
+package otelaurora
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mmatagrin/sql-builder/aurora"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/mmatagrin/sql-builder/otelaurora"
+
+type transactionSpanKey struct {
+	hook *TracingHook
+}
+
+//creates a span for every statement, batch and transaction operation.
+//the statements executed in a transaction are children of a span covering the whole transaction
+type TracingHook struct {
+	tracer trace.Tracer
+
+	mutex         sync.Mutex
+	parentContext context.Context
+	transactions  map[string]trace.Span
+}
+
+func NewTracingHook(provider trace.TracerProvider) *TracingHook {
+	return &TracingHook{
+		tracer:        provider.Tracer(instrumentationName),
+		parentContext: context.Background(),
+		transactions:  make(map[string]trace.Span),
+	}
+}
+
+//context the spans outside of a transaction are created in, ex: the context of the current lambda invocation
+func (th *TracingHook) SetParentContext(ctx context.Context) {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+
+	th.parentContext = ctx
+}
+
+func (th *TracingHook) BeforeQuery(event *aurora.QueryEvent) {
+	ctx := th.spanParent(event.TransactionId)
+
+	if event.Operation == aurora.BEGIN_TRANSACTION {
+		//the transaction span is ended on commit or rollback
+		var transactionSpan trace.Span
+		ctx, transactionSpan = th.tracer.Start(ctx, "Transaction", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(connexionAttributes(event)...))
+		event.SetValue(transactionSpanKey{th}, transactionSpan)
+	}
+
+	_, span := th.tracer.Start(ctx, string(event.Operation), trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(connexionAttributes(event)...))
+	if event.Sql != "" {
+		span.SetAttributes(attribute.String("db.statement", aurora.SanitizeSql(event.Sql, event.Dialect)))
+	}
+	event.SetValue(th, span)
+}
+
+func (th *TracingHook) AfterQuery(event *aurora.QueryEvent) {
+	span, ok := event.Value(th).(trace.Span)
+	if !ok {
+		return
+	}
+
+	endSpan(span, event)
+
+	switch event.Operation {
+	case aurora.BEGIN_TRANSACTION:
+		transactionSpan := event.Value(transactionSpanKey{th}).(trace.Span)
+		if event.Err != nil {
+			endSpan(transactionSpan, event)
+			return
+		}
+
+		transactionSpan.SetAttributes(attribute.String("db.aurora.transaction_id", event.TransactionId))
+		th.mutex.Lock()
+		th.transactions[event.TransactionId] = transactionSpan
+		th.mutex.Unlock()
+	case aurora.COMMIT_TRANSACTION, aurora.ROLLBACK_TRANSACTION:
+		th.mutex.Lock()
+		transactionSpan, found := th.transactions[event.TransactionId]
+		delete(th.transactions, event.TransactionId)
+		th.mutex.Unlock()
+
+		if found {
+			transactionSpan.SetAttributes(attribute.String("db.aurora.transaction_end", string(event.Operation)))
+			endSpan(transactionSpan, event)
+		}
+	}
+}
+
+//span of the transaction the operation belongs to, or the parent context
+func (th *TracingHook) spanParent(transactionId string) context.Context {
+	th.mutex.Lock()
+	defer th.mutex.Unlock()
+
+	if transactionSpan, found := th.transactions[transactionId]; found && transactionId != "" {
+		return trace.ContextWithSpan(th.parentContext, transactionSpan)
+	}
+
+	return th.parentContext
+}
+
+func connexionAttributes(event *aurora.QueryEvent) []attribute.KeyValue {
+	attributes := []attribute.KeyValue{
+		attribute.String("db.system", string(event.Dialect)),
+		attribute.String("db.name", event.Connexion.Database),
+	}
+
+	if event.TransactionId != "" {
+		attributes = append(attributes, attribute.String("db.aurora.transaction_id", event.TransactionId))
+	}
+
+	return attributes
+}
+
+func endSpan(span trace.Span, event *aurora.QueryEvent) {
+	//the Data API does not count the rows of a batch
+	if event.Operation == aurora.EXECUTE_STATEMENT {
+		span.SetAttributes(
+			attribute.Int64("db.aurora.rows_affected", event.RowsAffected),
+			attribute.Int("db.aurora.rows_returned", event.RowsReturned),
+		)
+	}
+
+	if event.Err != nil {
+		span.RecordError(event.Err)
+		span.SetStatus(codes.Error, event.Err.Error())
+	}
+
+	span.End()
+}
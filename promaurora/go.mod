@@ -0,0 +1,24 @@
+module github.com/mmatagrin/sql-builder/promaurora
+
+go 1.25.0
+
+require (
+	github.com/mmatagrin/sql-builder v0.0.0
+	github.com/prometheus/client_golang v1.24.1
+)
+
+require (
+	github.com/aws/aws-sdk-go v1.34.5 // indirect
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/jmespath/go-jmespath v0.3.0 // indirect
+	github.com/mmatagrin/ctxerror v0.0.0-20200813181857-5f4e18911605 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.2 // indirect
+	github.com/prometheus/common v0.70.1 // indirect
+	github.com/prometheus/procfs v0.21.1 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+	google.golang.org/protobuf v1.36.11 // indirect
+)
+
+replace github.com/mmatagrin/sql-builder => ../
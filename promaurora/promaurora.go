@@ -0,0 +1,82 @@
+//Package promaurora exposes the query metrics of the aurora package to Prometheus:
+//
+//	collector := promaurora.NewCollector("myapp")
+//	prometheus.MustRegister(collector)
+//	aurora.SetMetricsCollector(collector)
+package promaurora
+
+import (
+	"github.com/mmatagrin/sql-builder/aurora"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+//implements aurora.MetricsCollector and prometheus.Collector
+type Collector struct {
+	queries      *prometheus.CounterVec
+	errors       *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	rowsReturned *prometheus.HistogramVec
+}
+
+func NewCollector(namespace string) *Collector {
+	labels := []string{"name", "operation"}
+
+	return &Collector{
+		queries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "aurora",
+			Name:      "queries_total",
+			Help:      "Number of Data API calls.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "aurora",
+			Name:      "query_errors_total",
+			Help:      "Number of failed Data API calls by error class.",
+		}, append(labels, "error_class")),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "aurora",
+			Name:      "query_duration_seconds",
+			Help:      "Latency of the Data API calls.",
+			Buckets:   prometheus.DefBuckets,
+		}, labels),
+		rowsReturned: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "aurora",
+			Name:      "query_rows_returned",
+			Help:      "Number of rows returned by the statements.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 8),
+		}, labels),
+	}
+}
+
+func (c *Collector) ObserveQuery(metric aurora.QueryMetric) {
+	operation := string(metric.Operation)
+
+	c.queries.WithLabelValues(metric.Name, operation).Inc()
+	c.duration.WithLabelValues(metric.Name, operation).Observe(metric.Duration.Seconds())
+
+	if metric.ErrorClass != aurora.NO_ERROR {
+		c.errors.WithLabelValues(metric.Name, operation, string(metric.ErrorClass)).Inc()
+		return
+	}
+
+	if metric.Operation == aurora.EXECUTE_STATEMENT {
+		c.rowsReturned.WithLabelValues(metric.Name, operation).Observe(float64(metric.RowsReturned))
+	}
+}
+
+func (c *Collector) Describe(descs chan<- *prometheus.Desc) {
+	c.queries.Describe(descs)
+	c.errors.Describe(descs)
+	c.duration.Describe(descs)
+	c.rowsReturned.Describe(descs)
+}
+
+func (c *Collector) Collect(metrics chan<- prometheus.Metric) {
+	c.queries.Collect(metrics)
+	c.errors.Collect(metrics)
+	c.duration.Collect(metrics)
+	c.rowsReturned.Collect(metrics)
+}
@@ -0,0 +1,7 @@
+package structs
+
+type Assignment struct {
+	Column     string //when empty, Expression is a whole "column = value" assignment
+	Expression string //raw sql, when empty upserts use the value being inserted and updates bind Value
+	Value      interface{}
+}
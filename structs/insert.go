@@ -0,0 +1,14 @@
+package structs
+
+type Insert struct {
+	Into            string
+	Mode            int //insert, insert ignore, replace
+	Columns         []string
+	Values          [][]interface{}
+	Select          *Query       //INSERT INTO ... SELECT, replaces Values
+	Alias           string       //mysql row alias referenced by the updates
+	OnConflict      []string     //postgresql conflict target
+	Updates         []Assignment //ON DUPLICATE KEY UPDATE / ON CONFLICT DO UPDATE
+	UpdateCondition string       //condition the conflicting row must match to be updated
+	Returning       []string     //postgresql RETURNING columns
+}
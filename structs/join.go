@@ -6,6 +6,7 @@ type Join struct {
 	TargetTable string
 	PrimaryKey  string
 	ForeignKey  string
+	Conditions  []string //added to the join condition with AND
 }
 
 func InnerJoin(srcTable string, targetTable string, primaryKey string, foreignKey string) Join {
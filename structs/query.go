@@ -17,4 +17,8 @@ type Query struct {
 	GroupBy                 []string
 	Limit                   [2]int
 	Union                   []Query
+	Insert                  Insert
+	Update                  Update
+	Parameters              map[string]interface{}
+	Trashed                 int //soft deleted rows: excluded, included or only
 }
@@ -0,0 +1,7 @@
+package structs
+
+type Update struct {
+	Table      string
+	Set        []Assignment
+	SoftDelete bool //only updates the rows not already soft deleted
+}